@@ -0,0 +1,92 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package command
+
+import (
+	"fmt"
+
+	"github.com/evan-cleary/tf-schema-extractor/schemadiff"
+)
+
+func init() {
+	register("changelog", "Propose the next version and draft CHANGELOG entries", runChangelog)
+}
+
+func runChangelog(env *Env, args []string) int {
+	fs := newFlagSet(env, "changelog", "<old.json> <new.json>")
+	current := fs.String("version", "", "version of the old schema; read from it if unset")
+	bumpOnly := fs.Bool("bump", false, "only print the proposed next version")
+	output := fs.String("o", "-", "output file, \"-\" for stdout")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 2 {
+		return usageError(env, fs, "expected two schema files")
+	}
+
+	before, err := loadSchema(env, fs.Arg(0))
+	if err != nil {
+		return failure(env, "changelog", err)
+	}
+	after, err := loadSchema(env, fs.Arg(1))
+	if err != nil {
+		return failure(env, "changelog", err)
+	}
+	fromSchema := *current == ""
+	if fromSchema {
+		*current = before.Version
+	}
+
+	result := schemadiff.Compare(before, after)
+	bump := schemadiff.Bump(result, *current)
+	next := ""
+	if *current != "" {
+		if next, err = schemadiff.NextVersion(*current, bump); err != nil {
+			if !fromSchema {
+				return failure(env, "changelog", err)
+			}
+			// Schemas extracted from a development build carry a commit
+			// or nothing at all; draft an unreleased section instead
+			fmt.Fprintf(env.Stderr, "%s changelog: warning: old schema: %s\n", programName, err)
+			next = ""
+		}
+	}
+	if *bumpOnly {
+		if next == "" {
+			return usageError(env, fs, "-version is required with -bump if the old schema has no semantic version")
+		}
+		fmt.Fprintln(env.Stdout, next)
+		return ExitOK
+	}
+	if next == "" {
+		fmt.Fprintf(env.Stderr, "%s changelog: proposed version bump: %s\n", programName, bump)
+	} else {
+		fmt.Fprintf(env.Stderr, "%s changelog: proposed version bump: %s (%s -> %s)\n", programName, bump, *current, next)
+	}
+
+	w, closeOutput, discardOutput, err := createOutput(env, *output)
+	if err != nil {
+		return failure(env, "changelog", err)
+	}
+	if err = schemadiff.WriteChangelog(result, next, w); err != nil {
+		discardOutput()
+		return failure(env, "changelog", err)
+	}
+	if err = closeOutput(); err != nil {
+		return failure(env, "changelog", err)
+	}
+	return ExitOK
+}
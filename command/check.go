@@ -0,0 +1,98 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/evan-cleary/tf-schema-extractor/configcheck"
+	"github.com/evan-cleary/tf-schema-extractor/model"
+	"github.com/hashicorp/hcl/v2"
+)
+
+func init() {
+	register("check", "Validate Terraform configuration against schema files", runCheck)
+}
+
+// schemaPaths collects repeated -schema flags.
+type schemaPaths []string
+
+func (p *schemaPaths) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *schemaPaths) Set(value string) error {
+	*p = append(*p, value)
+	return nil
+}
+
+var checkFormats = []string{"text", "json"}
+
+func runCheck(env *Env, args []string) int {
+	fs := newFlagSet(env, "check", "<dir or file.tf>...")
+	var schemas schemaPaths
+	fs.Var(&schemas, "schema", "schema file of a provider used by the configuration; repeatable")
+	format := fs.String("format", "text", "output format: "+strings.Join(checkFormats, ", "))
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if len(schemas) == 0 {
+		return usageError(env, fs, "-schema is required")
+	}
+	if fs.NArg() == 0 {
+		return usageError(env, fs, "expected at least one configuration directory or file")
+	}
+	if *format != "text" && *format != "json" {
+		return usageError(env, fs, "unknown format %q", *format)
+	}
+
+	var loaded []*model.ResourceProviderSchema
+	for _, path := range schemas {
+		s, err := loadSchema(env, path)
+		if err != nil {
+			return failure(env, "check", err)
+		}
+		loaded = append(loaded, s)
+	}
+
+	checker := configcheck.New(loaded...)
+	var diags hcl.Diagnostics
+	for _, path := range fs.Args() {
+		found, err := checker.CheckPath(path)
+		if err != nil {
+			return failure(env, "check", err)
+		}
+		diags = append(diags, found...)
+	}
+
+	var err error
+	if *format == "json" {
+		err = configcheck.WriteJSON(diags, env.Stdout)
+	} else {
+		err = configcheck.WriteText(diags, checker.Files(), env.Stdout)
+	}
+	if err != nil {
+		return failure(env, "check", err)
+	}
+	if diags.HasErrors() {
+		return ExitFindings
+	}
+	if *format == "text" {
+		fmt.Fprintln(env.Stdout, "The configuration is valid.")
+	}
+	return ExitOK
+}
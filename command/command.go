@@ -0,0 +1,183 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package command
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/evan-cleary/tf-schema-extractor/model"
+)
+
+// Exit codes returned by Run.
+const (
+	ExitOK = 0
+	// The command failed, e.g. a file could not be read
+	ExitError = 1
+	// The command line was invalid
+	ExitUsage = 2
+	// The command ran, but found breaking changes or invalid schemas
+	ExitFindings = 3
+)
+
+const programName = "tf-schema-extractor"
+
+// ExportOptions are the flags of extract that a wrapper program passes
+// on to the extractor of its provider.
+type ExportOptions struct {
+	// Maximum nesting depth of schemas, model.DefaultMaxDepth if 0
+	MaxDepth int
+}
+
+// ExportFunc exports the schema of a provider linked into the program.
+type ExportFunc func(pi *model.ProviderInfo, opts ExportOptions) (*model.ResourceProviderSchema, error)
+
+// EncodeFunc writes the schema of a provider linked into the program to
+// w in the default format, without holding it in memory as a whole.
+type EncodeFunc func(pi *model.ProviderInfo, opts ExportOptions, w io.Writer) error
+
+// Env is what a command reads from and writes to. Results go to Stdout,
+// diagnostics and usage to Stderr.
+type Env struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// Set by wrapper programs that link a provider in, see Export
+	Export ExportFunc
+	// Optional; used by extract instead of Export for the default format
+	Encode EncodeFunc
+}
+
+// DefaultEnv returns an Env bound to the process's standard streams.
+func DefaultEnv() *Env {
+	return &Env{Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr}
+}
+
+type command struct {
+	synopsis string
+	run      func(env *Env, args []string) int
+}
+
+var commands = map[string]command{}
+
+func register(name, synopsis string, run func(env *Env, args []string) int) {
+	commands[name] = command{synopsis: synopsis, run: run}
+}
+
+// Run executes the subcommand named by args[0] and returns its exit code.
+func Run(env *Env, args []string) int {
+	if len(args) == 0 {
+		usage(env.Stderr)
+		return ExitUsage
+	}
+	switch args[0] {
+	case "-h", "-help", "--help", "help":
+		usage(env.Stdout)
+		return ExitOK
+	}
+	c, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(env.Stderr, "%s: unknown command %q\n\n", programName, args[0])
+		usage(env.Stderr)
+		return ExitUsage
+	}
+	return c.run(env, args[1:])
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags] [args]\n\nCommands:\n", programName)
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-12s %s\n", name, commands[name].synopsis)
+	}
+	fmt.Fprintf(w, "\nRun '%s <command> -h' for the flags of a command.\n", programName)
+}
+
+// newFlagSet returns a flag set that reports errors and usage on the
+// environment's Stderr instead of exiting.
+func newFlagSet(env *Env, name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(env.Stderr, "Usage: %s %s [flags] %s\n\nFlags:\n", programName, name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses args, returning the exit code to stop with if
+// parsing did not succeed.
+func parseFlags(fs *flag.FlagSet, args []string) (int, bool) {
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return ExitOK, false
+		}
+		return ExitUsage, false
+	}
+	return ExitOK, true
+}
+
+// usageError reports a problem with the command line.
+func usageError(env *Env, fs *flag.FlagSet, format string, a ...interface{}) int {
+	fmt.Fprintf(env.Stderr, "%s %s: %s\n\n", programName, fs.Name(), fmt.Sprintf(format, a...))
+	fs.Usage()
+	return ExitUsage
+}
+
+// failure reports an error that stopped the command.
+func failure(env *Env, name string, err error) int {
+	fmt.Fprintf(env.Stderr, "%s %s: %s\n", programName, name, err)
+	return ExitError
+}
+
+// loadSchema reads a schema file written by DoGenerate; "-" is stdin.
+func loadSchema(env *Env, path string) (*model.ResourceProviderSchema, error) {
+	if path == "-" {
+		return model.Load(env.Stdin)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	result, err := model.Load(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return result, nil
+}
+
+// createOutput opens the -o destination; "-" is stdout. A file is
+// replaced only once closeOutput is called, see model.OutputFile, so
+// that a command that fails leaves an existing file as it was.
+func createOutput(env *Env, path string) (w io.Writer, closeOutput func() error, discardOutput func(), err error) {
+	if path == "-" {
+		return env.Stdout, func() error { return nil }, func() {}, nil
+	}
+	file, err := model.CreateOutputFile(path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return file, file.Close, file.Discard, nil
+}
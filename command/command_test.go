@@ -0,0 +1,270 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/evan-cleary/tf-schema-extractor/model"
+)
+
+// testEnv is an Env backed by buffers.
+type testEnv struct {
+	Env
+	stdout, stderr bytes.Buffer
+}
+
+func newTestEnv(stdin string) *testEnv {
+	e := new(testEnv)
+	e.Stdin = strings.NewReader(stdin)
+	e.Stdout = &e.stdout
+	e.Stderr = &e.stderr
+	return e
+}
+
+func testSchema() *model.ResourceProviderSchema {
+	s := model.NewResourceProviderSchema(&model.ProviderInfo{Name: "test", Revision: "1.0.0"}, model.SDKTypeSDK2)
+	s.Provider["region"] = model.SchemaDefinition{Type: "String", Optional: true}
+	s.Resources["test_thing"] = model.SchemaInfoWithTimeouts{
+		"name": {Type: "String", Required: true},
+		"size": {Type: "Int", Optional: true, Default: &model.SchemaDefault{Source: model.DefaultSourceStatic, Value: 1}},
+	}
+	s.ResourceHeaders["test_thing"] = model.NewResourceHeader(true, true, true, true)
+	return s
+}
+
+// testDir returns a temporary directory holding the schema file
+// "test.json", and a function that removes the directory.
+func testDir(t *testing.T) (string, func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "command")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = model.WriteFile(testSchema(), filepath.Join(dir, "test.json")); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func encode(t *testing.T, write func(s *model.ResourceProviderSchema, w io.Writer) error) string {
+	t.Helper()
+	var b bytes.Buffer
+	if err := write(testSchema(), &b); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestRunExitCodes(t *testing.T) {
+	dir, cleanup := testDir(t)
+	defer cleanup()
+	invalid := filepath.Join(dir, "invalid.json")
+	s := testSchema()
+	s.Resources["test_thing"]["name"] = model.SchemaDefinition{Type: "Text", Required: true}
+	if err := model.WriteFile(s, invalid); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"no command", nil, ExitUsage},
+		{"help", []string{"help"}, ExitOK},
+		{"unknown command", []string{"frobnicate"}, ExitUsage},
+		{"flag help", []string{"convert", "-h"}, ExitOK},
+		{"unknown flag", []string{"convert", "-frobnicate", "x"}, ExitUsage},
+		{"missing argument", []string{"convert"}, ExitUsage},
+		{"unknown format", []string{"convert", "-format", "yaml", filepath.Join(dir, "test.json")}, ExitUsage},
+		{"missing file", []string{"convert", filepath.Join(dir, "missing.json")}, ExitError},
+		{"converted", []string{"convert", filepath.Join(dir, "test.json")}, ExitOK},
+		{"valid", []string{"validate", filepath.Join(dir, "test.json")}, ExitOK},
+		{"invalid", []string{"validate", invalid}, ExitFindings},
+		{"no provider", []string{"extract", "-name", "test"}, ExitUsage},
+		{"max depth", []string{"extract", "-name", "test", "-max-depth", "0"}, ExitUsage},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			env := newTestEnv("")
+			if got := Run(&env.Env, test.args); got != test.want {
+				t.Errorf("Run(%q) = %d, want %d; stderr:\n%s", test.args, got, test.want, env.stderr.String())
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	dir, cleanup := testDir(t)
+	defer cleanup()
+	jetbrains := encode(t, model.Write)
+
+	env := newTestEnv(jetbrains)
+	if code := Run(&env.Env, []string{"convert", "-format", "jetbrains-inline", "-"}); code != ExitOK {
+		t.Fatalf("convert = %d; stderr:\n%s", code, env.stderr.String())
+	}
+	if want := encode(t, model.WriteInline); env.stdout.String() != want {
+		t.Errorf("convert -format jetbrains-inline =\n%s\nwant\n%s", env.stdout.String(), want)
+	}
+
+	output := filepath.Join(dir, "inline.json")
+	env = newTestEnv("")
+	if code := Run(&env.Env, []string{"convert", "-format", "jetbrains-inline", "-o", output, filepath.Join(dir, "test.json")}); code != ExitOK {
+		t.Fatalf("convert -o = %d; stderr:\n%s", code, env.stderr.String())
+	}
+	if env.stdout.Len() > 0 {
+		t.Errorf("convert -o wrote to stdout:\n%s", env.stdout.String())
+	}
+	file, err := os.Open(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	s, err := model.Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := encode(t, func(_ *model.ResourceProviderSchema, w io.Writer) error { return model.Write(s, w) }); got != jetbrains {
+		t.Errorf("converted schema =\n%s\nwant\n%s", got, jetbrains)
+	}
+}
+
+func TestConvertWarnings(t *testing.T) {
+	s := testSchema()
+	s.Resources["test_thing"]["tree"] = model.SchemaDefinition{Type: "List", Optional: true, IsBlock: true,
+		Elem: &model.SchemaElement{Type: model.ElementTypeBackRef, Ref: "test_thing"}}
+	var b bytes.Buffer
+	if err := model.Write(s, &b); err != nil {
+		t.Fatal(err)
+	}
+	env := newTestEnv(b.String())
+	if code := Run(&env.Env, []string{"convert", "-format", "terraform-json", "-"}); code != ExitOK {
+		t.Fatalf("convert = %d; stderr:\n%s", code, env.stderr.String())
+	}
+	if want := "tf-schema-extractor convert: warning: test_thing.tree:"; !strings.HasPrefix(env.stderr.String(), want) {
+		t.Errorf("stderr = %q, want a warning starting with %q", env.stderr.String(), want)
+	}
+	if !json.Valid(env.stdout.Bytes()) {
+		t.Errorf("stdout is not JSON:\n%s", env.stdout.String())
+	}
+}
+
+func TestOutputReplacement(t *testing.T) {
+	dir, cleanup := testDir(t)
+	defer cleanup()
+	output := filepath.Join(dir, "out.json")
+	if err := ioutil.WriteFile(output, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	env := newTestEnv("")
+	env.Encode = func(pi *model.ProviderInfo, opts ExportOptions, w io.Writer) error {
+		io.WriteString(w, "partial")
+		return errors.New("provider failed")
+	}
+	if code := Run(&env.Env, []string{"extract", "-name", "test", "-o", output}); code != ExitError {
+		t.Errorf("failed extract = %d, want %d", code, ExitError)
+	}
+	if want := "tf-schema-extractor extract: provider failed\n"; env.stderr.String() != want {
+		t.Errorf("stderr = %q, want %q", env.stderr.String(), want)
+	}
+	assertOutput(t, dir, output, "old")
+
+	env = newTestEnv("")
+	env.Encode = func(pi *model.ProviderInfo, opts ExportOptions, w io.Writer) error {
+		if pi.Name != "test" || opts.MaxDepth != 64 {
+			t.Errorf("Encode(%+v, %+v)", pi, opts)
+		}
+		_, err := io.WriteString(w, "new")
+		return err
+	}
+	if code := Run(&env.Env, []string{"extract", "-name", "test", "-max-depth", "64", "-o", output}); code != ExitOK {
+		t.Errorf("extract = %d; stderr:\n%s", code, env.stderr.String())
+	}
+	assertOutput(t, dir, output, "new")
+	if info, err := os.Stat(output); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("output mode = %v, %v; want the replaced file's 0600", info.Mode().Perm(), err)
+	}
+}
+
+// assertOutput checks the content of output, and that no temporary
+// files are left next to it.
+func assertOutput(t *testing.T, dir, output, content string) {
+	t.Helper()
+	data, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != content {
+		t.Errorf("output = %q, want %q", data, content)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		if strings.HasSuffix(f.Name(), ".tmp") {
+			t.Errorf("temporary file %s left", f.Name())
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	dir, cleanup := testDir(t)
+	defer cleanup()
+	env := newTestEnv("")
+	if code := Run(&env.Env, []string{"fingerprint", filepath.Join(dir, "test.json")}); code != ExitOK {
+		t.Fatalf("fingerprint = %d; stderr:\n%s", code, env.stderr.String())
+	}
+	var got model.Fingerprints
+	if err := json.Unmarshal(env.stdout.Bytes(), &got); err != nil {
+		t.Fatalf("fingerprint output: %s\n%s", err, env.stdout.String())
+	}
+	want := model.Fingerprint(testSchema())
+	if !reflect.DeepEqual(&got, want) {
+		t.Errorf("fingerprint = %+v, want %+v", got, want)
+	}
+
+	// Fingerprints do not depend on the provider's version
+	s := testSchema()
+	s.Version = "2.0.0"
+	var b bytes.Buffer
+	if err := model.Write(s, &b); err != nil {
+		t.Fatal(err)
+	}
+	env = newTestEnv(b.String())
+	if code := Run(&env.Env, []string{"fingerprint", "-"}); code != ExitOK {
+		t.Fatalf("fingerprint - = %d; stderr:\n%s", code, env.stderr.String())
+	}
+	var other model.Fingerprints
+	if err := json.Unmarshal(env.stdout.Bytes(), &other); err != nil {
+		t.Fatal(err)
+	}
+	if other.Provider != got.Provider {
+		t.Errorf("fingerprint changed with the version: %s, was %s", other.Provider, got.Provider)
+	}
+}
@@ -0,0 +1,44 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package command
+
+func init() {
+	register("convert", "Convert a schema file to another output format", runConvert)
+}
+
+func runConvert(env *Env, args []string) int {
+	fs := newFlagSet(env, "convert", "<schema.json>")
+	format := fs.String("format", defaultFormat, "output format: "+formatNames())
+	output := fs.String("o", "-", "output file, \"-\" for stdout")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 1 {
+		return usageError(env, fs, "expected one schema file, \"-\" for stdin")
+	}
+	if _, ok := formats[*format]; !ok {
+		return usageError(env, fs, "unknown format %q", *format)
+	}
+
+	s, err := loadSchema(env, fs.Arg(0))
+	if err != nil {
+		return failure(env, "convert", err)
+	}
+	if err = writeSchema(env, "convert", s, *format, *output); err != nil {
+		return failure(env, "convert", err)
+	}
+	return ExitOK
+}
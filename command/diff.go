@@ -0,0 +1,68 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package command
+
+import (
+	"strings"
+
+	"github.com/evan-cleary/tf-schema-extractor/schemadiff"
+)
+
+func init() {
+	register("diff", "Compare two schema files and classify the changes", runDiff)
+}
+
+func runDiff(env *Env, args []string) int {
+	fs := newFlagSet(env, "diff", "<old.json> <new.json>")
+	format := fs.String("format", "text", "output format: "+strings.Join(schemadiff.FormatNames(), ", "))
+	output := fs.String("o", "-", "output file, \"-\" for stdout")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 2 {
+		return usageError(env, fs, "expected two schema files")
+	}
+	write, ok := schemadiff.Formats[*format]
+	if !ok {
+		return usageError(env, fs, "unknown format %q", *format)
+	}
+
+	before, err := loadSchema(env, fs.Arg(0))
+	if err != nil {
+		return failure(env, "diff", err)
+	}
+	after, err := loadSchema(env, fs.Arg(1))
+	if err != nil {
+		return failure(env, "diff", err)
+	}
+
+	result := schemadiff.Compare(before, after)
+	w, closeOutput, discardOutput, err := createOutput(env, *output)
+	if err != nil {
+		return failure(env, "diff", err)
+	}
+	if err = write(result, w); err != nil {
+		discardOutput()
+		return failure(env, "diff", err)
+	}
+	if err = closeOutput(); err != nil {
+		return failure(env, "diff", err)
+	}
+	if result.Breaking() {
+		return ExitFindings
+	}
+	return ExitOK
+}
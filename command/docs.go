@@ -0,0 +1,46 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package command
+
+import (
+	"github.com/evan-cleary/tf-schema-extractor/docs"
+)
+
+func init() {
+	register("docs", "Generate Registry documentation from a schema file", runDocs)
+}
+
+func runDocs(env *Env, args []string) int {
+	fs := newFlagSet(env, "docs", "<schema.json>")
+	var options docs.Options
+	fs.StringVar(&options.TemplateDir, "templates", "", "directory of templates overriding the built-in ones")
+	output := fs.String("o", "docs", "output directory")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 1 {
+		return usageError(env, fs, "expected one schema file, \"-\" for stdin")
+	}
+
+	s, err := loadSchema(env, fs.Arg(0))
+	if err != nil {
+		return failure(env, "docs", err)
+	}
+	if err = docs.Generate(s, *output, options); err != nil {
+		return failure(env, "docs", err)
+	}
+	return ExitOK
+}
@@ -0,0 +1,106 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package command
+
+import (
+	"fmt"
+
+	"github.com/evan-cleary/tf-schema-extractor/model"
+	plugin "github.com/evan-cleary/tf-schema-extractor/plugin/extractor"
+)
+
+func init() {
+	register("extract", "Extract the schema of a provider", runExtract)
+}
+
+func runExtract(env *Env, args []string) int {
+	fs := newFlagSet(env, "extract", "")
+	pi := new(model.ProviderInfo)
+	provider := fs.String("provider", "", "provider binary to extract over the plugin protocol")
+	fs.StringVar(&pi.Name, "name", "", "provider name, e.g. \"aws\"; derived from the -provider file name if unset")
+	fs.StringVar(&pi.Revision, "version", "", "provider version recorded in the schema")
+	format := fs.String("format", defaultFormat, "output format: "+formatNames())
+	output := fs.String("o", "-", "output file, \"-\" for stdout")
+	var opts ExportOptions
+	fs.IntVar(&opts.MaxDepth, "max-depth", model.DefaultMaxDepth, "maximum nesting depth of the schemas of a linked-in provider")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 0 {
+		return usageError(env, fs, "unexpected arguments %q", fs.Args())
+	}
+	if opts.MaxDepth < 1 {
+		return usageError(env, fs, "-max-depth must be at least 1")
+	}
+	if *provider != "" {
+		derived := plugin.InfoFromPath(*provider)
+		if pi.Name == "" {
+			pi.Name = derived.Name
+		}
+		if pi.Revision == "" {
+			pi.Revision = derived.Revision
+		}
+	}
+	if pi.Name == "" {
+		return usageError(env, fs, "-name is required")
+	}
+	if _, ok := formats[*format]; !ok {
+		return usageError(env, fs, "unknown format %q", *format)
+	}
+
+	if *provider == "" && env.Encode != nil && *format == defaultFormat {
+		if err := encodeSchema(env, pi, opts, *output); err != nil {
+			return failure(env, "extract", err)
+		}
+		return ExitOK
+	}
+
+	var result *model.ResourceProviderSchema
+	var err error
+	switch {
+	case *provider != "":
+		var problems []string
+		result, problems, err = new(plugin.PluginExtractor).Export(*provider, pi)
+		for _, problem := range problems {
+			fmt.Fprintf(env.Stderr, "%s extract: warning: %s\n", programName, problem)
+		}
+	case env.Export != nil:
+		result, err = env.Export(pi, opts)
+	default:
+		return usageError(env, fs, "-provider is required")
+	}
+	if err != nil {
+		return failure(env, "extract", err)
+	}
+	if err = writeSchema(env, "extract", result, *format, *output); err != nil {
+		return failure(env, "extract", err)
+	}
+	return ExitOK
+}
+
+// encodeSchema streams the schema of the linked-in provider to the -o
+// destination.
+func encodeSchema(env *Env, pi *model.ProviderInfo, opts ExportOptions, output string) error {
+	w, closeOutput, discardOutput, err := createOutput(env, output)
+	if err != nil {
+		return err
+	}
+	if err = env.Encode(pi, opts, w); err != nil {
+		discardOutput()
+		return err
+	}
+	return closeOutput()
+}
@@ -0,0 +1,60 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package command
+
+import (
+	"encoding/json"
+
+	"github.com/evan-cleary/tf-schema-extractor/model"
+)
+
+func init() {
+	register("fingerprint", "Print content hashes of a schema's parts", runFingerprint)
+}
+
+func runFingerprint(env *Env, args []string) int {
+	fs := newFlagSet(env, "fingerprint", "<schema.json>")
+	output := fs.String("o", "-", "output file, \"-\" for stdout")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 1 {
+		return usageError(env, fs, "expected one schema file, \"-\" for stdin")
+	}
+
+	s, err := loadSchema(env, fs.Arg(0))
+	if err != nil {
+		return failure(env, "fingerprint", err)
+	}
+	data, err := json.MarshalIndent(model.Fingerprint(s), "", "  ")
+	if err != nil {
+		return failure(env, "fingerprint", err)
+	}
+	data = append(data, '\n')
+
+	w, closeOutput, discardOutput, err := createOutput(env, *output)
+	if err != nil {
+		return failure(env, "fingerprint", err)
+	}
+	if _, err = w.Write(data); err != nil {
+		discardOutput()
+		return failure(env, "fingerprint", err)
+	}
+	if err = closeOutput(); err != nil {
+		return failure(env, "fingerprint", err)
+	}
+	return ExitOK
+}
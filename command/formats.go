@@ -0,0 +1,83 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package command
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/evan-cleary/tf-schema-extractor/coreschema"
+	"github.com/evan-cleary/tf-schema-extractor/jsonschema"
+	"github.com/evan-cleary/tf-schema-extractor/model"
+)
+
+// formatFunc writes a schema in one output format, and returns the parts
+// of the schema the format cannot express.
+type formatFunc func(s *model.ResourceProviderSchema, w io.Writer) ([]string, error)
+
+// lossless adapts write, which can express any schema, to a formatFunc.
+func lossless(write func(s *model.ResourceProviderSchema, w io.Writer) error) formatFunc {
+	return func(s *model.ResourceProviderSchema, w io.Writer) ([]string, error) {
+		return nil, write(s, w)
+	}
+}
+
+const defaultFormat = "jetbrains"
+
+var formats = map[string]formatFunc{
+	// The format written by DoGenerate
+	"jetbrains": lossless(model.Write),
+	// The same with nested schemas inline instead of in a definitions
+	// table, as written before schema version 3
+	"jetbrains-inline": lossless(model.WriteInline),
+	// The format of "terraform providers schema -json"
+	"terraform-json": coreschema.Write,
+	// A JSON Schema for .tf.json files using the provider
+	"json-schema": lossless(jsonschema.Write),
+}
+
+func formatNames() string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// writeSchema writes s in the given format to the -o destination, and
+// warns about what the format cannot express as command.
+func writeSchema(env *Env, command string, s *model.ResourceProviderSchema, format, output string) error {
+	write, ok := formats[format]
+	if !ok {
+		return fmt.Errorf("unknown format %q, expected one of %s", format, formatNames())
+	}
+	w, closeOutput, discardOutput, err := createOutput(env, output)
+	if err != nil {
+		return err
+	}
+	problems, err := write(s, w)
+	if err != nil {
+		discardOutput()
+		return err
+	}
+	for _, problem := range problems {
+		fmt.Fprintf(env.Stderr, "%s %s: warning: %s\n", programName, command, problem)
+	}
+	return closeOutput()
+}
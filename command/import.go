@@ -0,0 +1,147 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/evan-cleary/tf-schema-extractor/coreschema"
+	"github.com/evan-cleary/tf-schema-extractor/model"
+)
+
+func init() {
+	register("import", "Convert \"terraform providers schema -json\" output", runImport)
+}
+
+func runImport(env *Env, args []string) int {
+	fs := newFlagSet(env, "import", "<schemas.json>")
+	provider := fs.String("provider", "", "source address or name of the provider to import, e.g. \"hashicorp/aws\"")
+	version := fs.String("version", "", "provider version recorded in the schema")
+	format := fs.String("format", defaultFormat, "output format: "+formatNames())
+	output := fs.String("o", "-", "output file, \"-\" for stdout")
+	dir := fs.String("dir", "", "write every provider to <dir>/<name>.json instead of -o")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 1 {
+		return usageError(env, fs, "expected one schemas file, \"-\" for stdin")
+	}
+	if *dir != "" && *output != "-" {
+		return usageError(env, fs, "-o and -dir are mutually exclusive")
+	}
+	if _, ok := formats[*format]; !ok {
+		return usageError(env, fs, "unknown format %q", *format)
+	}
+
+	doc, err := loadProviderSchemas(env, fs.Arg(0))
+	if err != nil {
+		return failure(env, "import", err)
+	}
+	schemas, problems := coreschema.Import(doc)
+
+	addresses := coreschema.Addresses(doc)
+	if *provider != "" {
+		address, err := selectProvider(addresses, *provider)
+		if err != nil {
+			return failure(env, "import", err)
+		}
+		addresses = []string{address}
+	}
+	for _, address := range addresses {
+		schemas[address].Version = *version
+		for _, problem := range problems {
+			if strings.HasPrefix(problem, address+": ") {
+				fmt.Fprintf(env.Stderr, "%s import: warning: %s\n", programName, problem)
+			}
+		}
+	}
+
+	if *dir == "" {
+		if len(addresses) != 1 {
+			return usageError(env, fs, "found %d providers, select one with -provider or use -dir", len(addresses))
+		}
+		err = writeSchema(env, "import", schemas[addresses[0]], *format, *output)
+	} else {
+		err = writeSchemas(env, schemas, addresses, *format, *dir)
+	}
+	if err != nil {
+		return failure(env, "import", err)
+	}
+	return ExitOK
+}
+
+// loadProviderSchemas reads a "terraform providers schema -json"
+// document; "-" is stdin.
+func loadProviderSchemas(env *Env, path string) (*coreschema.ProviderSchemas, error) {
+	if path == "-" {
+		return coreschema.Load(env.Stdin)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	result, err := coreschema.Load(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return result, nil
+}
+
+// selectProvider finds the address matching query, which is either a
+// full source address, its namespace and type, or the bare type.
+func selectProvider(addresses []string, query string) (string, error) {
+	var matches []string
+	for _, address := range addresses {
+		if address == query || coreschema.ProviderName(address) == query ||
+			strings.HasSuffix(address, "/"+query) {
+			matches = append(matches, address)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no provider %q in %v", query, addresses)
+	case 1:
+		return matches[0], nil
+	}
+	return "", fmt.Errorf("provider %q is ambiguous, matches %v", query, matches)
+}
+
+// writeSchemas writes each provider to <dir>/<name>.json, like Generate.
+func writeSchemas(env *Env, schemas map[string]*model.ResourceProviderSchema, addresses []string, format, dir string) error {
+	written := make(map[string]string)
+	for _, address := range addresses {
+		s := schemas[address]
+		if other, ok := written[s.Name]; ok {
+			return fmt.Errorf("providers %s and %s would both be written to %s.json", other, address, s.Name)
+		}
+		written[s.Name] = address
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, address := range addresses {
+		s := schemas[address]
+		if err := writeSchema(env, "import", s, format, filepath.Join(dir, s.Name+".json")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
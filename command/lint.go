@@ -0,0 +1,134 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package command
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/evan-cleary/tf-schema-extractor/lint"
+)
+
+func init() {
+	register("lint", "Check a schema file for authoring problems", runLint)
+}
+
+// severities collects repeated -rule name=severity flags.
+type severities map[string]string
+
+func (s severities) String() string {
+	return ""
+}
+
+func (s severities) Set(value string) error {
+	i := strings.Index(value, "=")
+	if i < 0 {
+		return fmt.Errorf("expected name=severity, got %q", value)
+	}
+	s[value[:i]] = value[i+1:]
+	return nil
+}
+
+func runLint(env *Env, args []string) int {
+	fs := newFlagSet(env, "lint", "<schema.json>")
+	configPath := fs.String("config", "", "JSON file selecting rules and their severities")
+	rules := severities{}
+	fs.Var(rules, "rule", "set the severity of a rule, e.g. \"snake-case=error\" or \"missing-description=off\"; repeatable")
+	format := fs.String("format", "text", "output format: "+strings.Join(lint.FormatNames(), ", "))
+	output := fs.String("o", "-", "output file, \"-\" for stdout")
+	list := fs.Bool("list", false, "list the rules and exit")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *list {
+		for _, name := range sortedRuleNames(lint.Rules) {
+			rule := lint.Rules[name]
+			fmt.Fprintf(env.Stdout, "%-22s %-8s %s\n", rule.Name, rule.Severity, rule.Description)
+		}
+		return ExitOK
+	}
+	if fs.NArg() != 1 {
+		return usageError(env, fs, "expected one schema file, \"-\" for stdin")
+	}
+	write, ok := lint.Formats[*format]
+	if !ok {
+		return usageError(env, fs, "unknown format %q", *format)
+	}
+
+	config := &lint.Config{Rules: map[string]string{}}
+	if *configPath != "" {
+		file, err := os.Open(*configPath)
+		if err != nil {
+			return failure(env, "lint", err)
+		}
+		config, err = lint.LoadConfig(file)
+		file.Close()
+		if err != nil {
+			return failure(env, "lint", fmt.Errorf("%s: %s", *configPath, err))
+		}
+		if config.Rules == nil {
+			config.Rules = map[string]string{}
+		}
+	}
+	for name, severity := range rules {
+		config.Rules[name] = severity
+	}
+	if err := config.Validate(); err != nil {
+		return usageError(env, fs, "%s", err)
+	}
+
+	s, err := loadSchema(env, fs.Arg(0))
+	if err != nil {
+		return failure(env, "lint", err)
+	}
+	findings, err := lint.Lint(s, config)
+	if err != nil {
+		return failure(env, "lint", err)
+	}
+
+	artifact := fs.Arg(0)
+	if artifact == "-" {
+		artifact = ""
+	}
+	w, closeOutput, discardOutput, err := createOutput(env, *output)
+	if err != nil {
+		return failure(env, "lint", err)
+	}
+	if err = write(findings, artifact, w); err != nil {
+		discardOutput()
+		return failure(env, "lint", err)
+	}
+	if err = closeOutput(); err != nil {
+		return failure(env, "lint", err)
+	}
+	for _, f := range findings {
+		if f.Severity == lint.SeverityError {
+			return ExitFindings
+		}
+	}
+	return ExitOK
+}
+
+func sortedRuleNames(rules map[string]*lint.Rule) []string {
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
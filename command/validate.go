@@ -0,0 +1,57 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package command
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/evan-cleary/tf-schema-extractor/model"
+)
+
+func init() {
+	register("validate", "Check that schema files conform to the output format", runValidate)
+}
+
+func runValidate(env *Env, args []string) int {
+	fs := newFlagSet(env, "validate", "<schema.json>...")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() == 0 {
+		return usageError(env, fs, "expected at least one schema file")
+	}
+
+	code := ExitOK
+	for _, path := range fs.Args() {
+		file, err := os.Open(path)
+		if err != nil {
+			return failure(env, "validate", err)
+		}
+		s, err := model.Load(file)
+		file.Close()
+		if err != nil {
+			fmt.Fprintf(env.Stdout, "%s: %s\n", path, err)
+			code = ExitFindings
+			continue
+		}
+		for _, problem := range model.Check(s) {
+			fmt.Fprintf(env.Stdout, "%s: %s\n", path, problem)
+			code = ExitFindings
+		}
+	}
+	return code
+}
@@ -0,0 +1,440 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package configcheck
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/evan-cleary/tf-schema-extractor/model"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Checker validates Terraform configurations against extracted schemas
+// without provider binaries or network access. Only what can be decided
+// statically is checked: expressions that refer to variables, resources
+// or functions are skipped.
+type Checker struct {
+	// Schemas by provider name
+	providers map[string]*model.ResourceProviderSchema
+	parser    *hclparse.Parser
+}
+
+// New returns a Checker for configurations using the given providers.
+// Blocks of other providers are not checked.
+func New(schemas ...*model.ResourceProviderSchema) *Checker {
+	c := &Checker{providers: make(map[string]*model.ResourceProviderSchema), parser: hclparse.NewParser()}
+	for _, s := range schemas {
+		c.providers[s.Name] = s
+	}
+	return c
+}
+
+// Files returns the files parsed so far, for rendering diagnostics with
+// their source.
+func (c *Checker) Files() map[string]*hcl.File {
+	return c.parser.Files()
+}
+
+// CheckPath checks a configuration file, or every .tf and .tf.json file
+// of a directory like Terraform does for a module.
+func (c *Checker) CheckPath(path string) (hcl.Diagnostics, error) {
+	matches, err := filepath.Glob(filepath.Join(path, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+	jsonMatches, err := filepath.Glob(filepath.Join(path, "*.tf.json"))
+	if err != nil {
+		return nil, err
+	}
+	files := append(matches, jsonMatches...)
+	if len(files) == 0 {
+		files = []string{path}
+	}
+	sort.Strings(files)
+
+	var diags hcl.Diagnostics
+	for _, filename := range files {
+		src, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		diags = append(diags, c.CheckFile(filename, src)...)
+	}
+	return diags, nil
+}
+
+// CheckFile checks the configuration in src; files whose name ends in
+// ".json" are read as Terraform's JSON syntax.
+func (c *Checker) CheckFile(filename string, src []byte) hcl.Diagnostics {
+	var file *hcl.File
+	var diags hcl.Diagnostics
+	if strings.HasSuffix(filename, ".json") {
+		file, diags = c.parser.ParseJSON(src, filename)
+	} else {
+		file, diags = c.parser.ParseHCL(src, filename)
+	}
+	if diags.HasErrors() {
+		return diags
+	}
+
+	content, _, moreDiags := file.Body.PartialContent(fileSchema)
+	diags = append(diags, moreDiags...)
+	for _, block := range content.Blocks {
+		switch block.Type {
+		case "provider":
+			diags = append(diags, c.checkProvider(block)...)
+		case "resource":
+			diags = append(diags, c.checkResource(block, "resource", resourceMetaArguments)...)
+		case "data":
+			diags = append(diags, c.checkResource(block, "data source", dataMetaArguments)...)
+		}
+	}
+	return diags
+}
+
+var fileSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "provider", LabelNames: []string{"name"}},
+		{Type: "resource", LabelNames: []string{"type", "name"}},
+		{Type: "data", LabelNames: []string{"type", "name"}},
+	},
+}
+
+// metaArguments are the arguments and blocks Terraform itself handles.
+type metaArguments struct {
+	attributes []string
+	blocks     []hcl.BlockHeaderSchema
+}
+
+var (
+	providerMetaArguments = metaArguments{attributes: []string{"alias", "version"}}
+	dataMetaArguments     = metaArguments{
+		attributes: []string{"count", "for_each", "provider", "depends_on"},
+		blocks:     []hcl.BlockHeaderSchema{{Type: "lifecycle"}},
+	}
+	resourceMetaArguments = metaArguments{
+		attributes: dataMetaArguments.attributes,
+		blocks: []hcl.BlockHeaderSchema{
+			{Type: "lifecycle"},
+			{Type: "connection"},
+			{Type: "provisioner", LabelNames: []string{"type"}},
+		},
+	}
+)
+
+func (c *Checker) checkProvider(block *hcl.Block) hcl.Diagnostics {
+	s, ok := c.providers[block.Labels[0]]
+	if !ok {
+		return nil
+	}
+	return c.checkBody(s, block.Body, s.Provider, "", providerMetaArguments)
+}
+
+func (c *Checker) checkResource(block *hcl.Block, kind string, meta metaArguments) hcl.Diagnostics {
+	resourceType := block.Labels[0]
+	s, ok := c.providers[c.providerName(block)]
+	if !ok {
+		return nil
+	}
+	resources := s.Resources
+	if kind == "data source" {
+		resources = s.DataSources
+	}
+	info, ok := resources[resourceType]
+	if !ok {
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid " + kind + " type",
+			Detail:   fmt.Sprintf("The provider %s does not support %s %q.", s.Name, kind, resourceType),
+			Subject:  block.LabelRanges[0].Ptr(),
+		}}
+	}
+
+	schema := model.SchemaInfo(info)
+	if _, ok := schema["id"]; !ok && implicitID(s) {
+		schema = make(model.SchemaInfo)
+		for k, d := range info {
+			schema[k] = d
+		}
+		schema["id"] = model.SchemaDefinition{Type: "String", Optional: true, Computed: true}
+	}
+	return c.checkBody(s, block.Body, schema, "", meta)
+}
+
+// implicitID reports whether Terraform sees an "id" attribute in every
+// resource of s that does not declare one, as helper/schema adds it.
+func implicitID(s *model.ResourceProviderSchema) bool {
+	return s.SDKType == "" || s.SDKType == model.SDKTypeSDK || s.SDKType == model.SDKTypeSDK2
+}
+
+// providerName returns the name of the provider a resource block
+// belongs to: that of its provider meta-argument, or else the prefix of
+// its type.
+func (c *Checker) providerName(block *hcl.Block) string {
+	content, _, diags := block.Body.PartialContent(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "provider"}},
+	})
+	if attr, ok := content.Attributes["provider"]; ok && !diags.HasErrors() {
+		if traversal, diags := hcl.AbsTraversalForExpr(attr.Expr); !diags.HasErrors() {
+			return traversal.RootName()
+		}
+	}
+	return strings.SplitN(block.Labels[0], "_", 2)[0]
+}
+
+// checkBody checks a block against info, part of s. prefix is the path
+// of the block within its resource in the form used by ConflictsWith,
+// e.g. "rule.0.".
+func (c *Checker) checkBody(s *model.ResourceProviderSchema, body hcl.Body, info model.SchemaInfo, prefix string, meta metaArguments) hcl.Diagnostics {
+	schema := &hcl.BodySchema{}
+	for _, name := range meta.attributes {
+		schema.Attributes = append(schema.Attributes, hcl.AttributeSchema{Name: name})
+	}
+	schema.Blocks = append(schema.Blocks, meta.blocks...)
+	hasBlocks := false
+	for _, name := range sortedNames(info) {
+		d := info[name]
+		if isBlock(s, d) {
+			hasBlocks = true
+			schema.Blocks = append(schema.Blocks, hcl.BlockHeaderSchema{Type: name})
+		} else {
+			schema.Attributes = append(schema.Attributes, hcl.AttributeSchema{Name: name, Required: d.Required})
+		}
+	}
+	if hasBlocks {
+		schema.Blocks = append(schema.Blocks, hcl.BlockHeaderSchema{Type: "dynamic", LabelNames: []string{"type"}})
+	}
+
+	content, diags := body.Content(schema)
+	for _, name := range sortedAttributeNames(content.Attributes) {
+		attr := content.Attributes[name]
+		d, ok := info[name]
+		if !ok {
+			continue // a meta-argument
+		}
+		if !d.Required && !d.Optional {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid configuration for a computed attribute",
+				Detail:   fmt.Sprintf("Cannot configure a value for %q: its value will be decided automatically.", name),
+				Subject:  attr.NameRange.Ptr(),
+			})
+			continue
+		}
+		diags = append(diags, checkAttribute(attr, d)...)
+		diags = append(diags, checkConflicts(content.Attributes, attr, d, prefix)...)
+	}
+
+	blocks := make(map[string][]*hcl.Block)
+	dynamic := make(map[string]bool)
+	for _, block := range content.Blocks {
+		switch {
+		case block.Type == "dynamic":
+			d, ok := info[block.Labels[0]]
+			if !ok || !isBlock(s, d) {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Unsupported block type",
+					Detail:   fmt.Sprintf("Blocks of type %q are not expected here.", block.Labels[0]),
+					Subject:  block.LabelRanges[0].Ptr(),
+				})
+				continue
+			}
+			dynamic[block.Labels[0]] = true
+			diags = append(diags, c.checkDynamic(s, block, d, prefix+block.Labels[0]+".0.")...)
+		case isBlock(s, info[block.Type]):
+			blocks[block.Type] = append(blocks[block.Type], block)
+			nested, _ := blockInfo(s, info[block.Type])
+			diags = append(diags, c.checkBody(s, block.Body, nested, prefix+block.Type+".0.", metaArguments{})...)
+		}
+	}
+
+	for _, name := range sortedNames(info) {
+		d := info[name]
+		if !isBlock(s, d) || dynamic[name] {
+			continue
+		}
+		diags = append(diags, checkBlockCount(body, name, d, blocks[name])...)
+	}
+	return diags
+}
+
+// checkDynamic checks the content block of a dynamic block.
+func (c *Checker) checkDynamic(s *model.ResourceProviderSchema, block *hcl.Block, d model.SchemaDefinition, prefix string) hcl.Diagnostics {
+	content, diags := block.Body.Content(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "for_each", Required: true}, {Name: "iterator"}, {Name: "labels"}},
+		Blocks:     []hcl.BlockHeaderSchema{{Type: "content"}},
+	})
+	nested, _ := blockInfo(s, d)
+	for _, content := range content.Blocks {
+		diags = append(diags, c.checkBody(s, content.Body, nested, prefix, metaArguments{})...)
+	}
+	return diags
+}
+
+func checkBlockCount(body hcl.Body, name string, d model.SchemaDefinition, blocks []*hcl.Block) hcl.Diagnostics {
+	minItems := d.MinItems
+	if d.Required && minItems == 0 {
+		minItems = 1
+	}
+	switch {
+	case len(blocks) < minItems:
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Insufficient " + name + " blocks",
+			Detail:   fmt.Sprintf("At least %d %q blocks are required.", minItems, name),
+			Subject:  body.MissingItemRange().Ptr(),
+		}}
+	case d.MaxItems > 0 && len(blocks) > d.MaxItems:
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Too many " + name + " blocks",
+			Detail:   fmt.Sprintf("No more than %d %q blocks are allowed.", d.MaxItems, name),
+			Subject:  blocks[d.MaxItems].DefRange.Ptr(),
+		}}
+	}
+	return nil
+}
+
+// checkAttribute checks the item limits of a list or set whose value is
+// known statically. Single nested attributes hold one object, which the
+// limits do not apply to.
+func checkAttribute(attr *hcl.Attribute, d model.SchemaDefinition) hcl.Diagnostics {
+	if d.MaxItems == 0 && d.MinItems == 0 || d.Nesting == model.NestingSingle {
+		return nil
+	}
+	value, ok := staticValue(attr)
+	if !ok {
+		return nil
+	}
+	if t := value.Type(); !t.IsTupleType() && !t.IsListType() && !t.IsSetType() {
+		return nil
+	}
+	n := value.LengthInt()
+	switch {
+	case n < d.MinItems:
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Not enough list items",
+			Detail:   fmt.Sprintf("Attribute %q requires %d item minimum, but config has only %d declared.", attr.Name, d.MinItems, n),
+			Subject:  attr.Expr.Range().Ptr(),
+		}}
+	case d.MaxItems > 0 && n > d.MaxItems:
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Too many list items",
+			Detail:   fmt.Sprintf("Attribute %q supports %d item maximum, but config has %d declared.", attr.Name, d.MaxItems, n),
+			Subject:  attr.Expr.Range().Ptr(),
+		}}
+	}
+	return nil
+}
+
+// checkConflicts reports the attributes set next to attr that it
+// conflicts with. Only conflicts within the same block are checked.
+func checkConflicts(attrs hcl.Attributes, attr *hcl.Attribute, d model.SchemaDefinition, prefix string) hcl.Diagnostics {
+	if isNull(attr) {
+		return nil
+	}
+	var diags hcl.Diagnostics
+	for _, path := range d.ConflictsWith {
+		if !strings.HasPrefix(path, prefix) || strings.Contains(path[len(prefix):], ".") {
+			continue
+		}
+		other, ok := attrs[path[len(prefix):]]
+		if !ok || isNull(other) {
+			continue
+		}
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Conflicting configuration arguments",
+			Detail:   fmt.Sprintf("%q: conflicts with %s", attr.Name, other.Name),
+			Subject:  attr.NameRange.Ptr(),
+		})
+	}
+	return diags
+}
+
+// staticValue evaluates an expression that does not depend on anything.
+func staticValue(attr *hcl.Attribute) (cty.Value, bool) {
+	if len(attr.Expr.Variables()) > 0 {
+		return cty.NilVal, false
+	}
+	value, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || !value.IsWhollyKnown() || value.IsNull() {
+		return cty.NilVal, false
+	}
+	return value, true
+}
+
+// isNull reports whether an attribute is set to null, which is the
+// same as leaving it out.
+func isNull(attr *hcl.Attribute) bool {
+	if len(attr.Expr.Variables()) > 0 {
+		return false
+	}
+	value, diags := attr.Expr.Value(nil)
+	return !diags.HasErrors() && value.IsNull()
+}
+
+// isBlock reports whether d, part of s, is configured with block syntax.
+func isBlock(s *model.ResourceProviderSchema, d model.SchemaDefinition) bool {
+	_, ok := blockInfo(s, d)
+	return d.IsBlock && ok && (d.Required || d.Optional)
+}
+
+// blockInfo returns the schema of the nested block d, part of s, and
+// whether d has one. A block that refers back to a block further up has
+// that block's schema.
+func blockInfo(s *model.ResourceProviderSchema, d model.SchemaDefinition) (model.SchemaInfo, bool) {
+	switch {
+	case d.Elem == nil:
+		return nil, false
+	case d.Elem.Type == "SchemaInfo":
+		return d.Elem.Info, true
+	case d.Elem.Type != model.ElementTypeBackRef:
+		return nil, false
+	}
+	e, err := model.ResolveBackRef(s, d.Elem.Ref)
+	if err != nil || e.Type != "SchemaInfo" {
+		return nil, false
+	}
+	return e.Info, true
+}
+
+func sortedNames(info model.SchemaInfo) []string {
+	names := make([]string, 0, len(info))
+	for name := range info {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedAttributeNames(attrs hcl.Attributes) []string {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
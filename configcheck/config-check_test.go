@@ -0,0 +1,92 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package configcheck
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/evan-cleary/tf-schema-extractor/model"
+	"github.com/hashicorp/hcl/v2"
+)
+
+func testSchema() *model.ResourceProviderSchema {
+	s := model.NewResourceProviderSchema(&model.ProviderInfo{Name: "test"}, model.SDKTypeSDK2)
+	s.Provider["region"] = model.SchemaDefinition{Type: "String", Required: true}
+	s.Resources["test_thing"] = model.SchemaInfoWithTimeouts{
+		"name": {Type: "String", Required: true},
+		"ports": {Type: "List", Optional: true, MinItems: 1, MaxItems: 2,
+			Elem: &model.SchemaElement{Type: "SchemaElements", ElementsType: "Int", Elements: &model.SchemaDefinition{Type: "Int"}}},
+		"rule": {Type: "List", Required: true, IsBlock: true, MaxItems: 2, Elem: &model.SchemaElement{Type: "SchemaInfo", Info: model.SchemaInfo{
+			"from": {Type: "Int", Optional: true},
+			"to":   {Type: "Int", Optional: true},
+		}}},
+	}
+	return s
+}
+
+// describe renders diagnostics compactly with the position they start at.
+func describe(diags hcl.Diagnostics) []string {
+	var result []string
+	for _, d := range diags {
+		position := "no position"
+		if d.Subject != nil {
+			position = fmt.Sprintf("%s:%d,%d", filepath.Base(d.Subject.Filename), d.Subject.Start.Line, d.Subject.Start.Column)
+		}
+		result = append(result, position+": "+d.Summary)
+	}
+	return result
+}
+
+func TestCheckPath(t *testing.T) {
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{"valid.tf", nil},
+		{"unknown-attribute.tf", []string{
+			"unknown-attribute.tf:3,3: Unsupported argument",
+			"unknown-attribute.tf:6,5: Unsupported argument",
+		}},
+		{"unknown-block.tf", []string{
+			"unknown-block.tf:6,3: Unsupported block type",
+		}},
+		{"missing-required.tf", []string{
+			// Missing items are reported at the opening brace
+			"missing-required.tf:1,17: Missing required argument",
+			"missing-required.tf:4,27: Missing required argument",
+			"missing-required.tf:5,11: Not enough list items",
+			"missing-required.tf:4,27: Insufficient rule blocks",
+		}},
+		{"max-items.tf", []string{
+			"max-items.tf:3,11: Too many list items",
+			"max-items.tf:7,3: Too many rule blocks",
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.file, func(t *testing.T) {
+			diags, err := New(testSchema()).CheckPath(filepath.Join("testdata", test.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := describe(diags); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("CheckPath() =\n%q\nwant\n%q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package configcheck
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// WriteText writes diagnostics like Terraform does, quoting the source
+// of the files they refer to.
+func WriteText(diags hcl.Diagnostics, files map[string]*hcl.File, w io.Writer) error {
+	return hcl.NewDiagnosticTextWriter(w, files, 0, false).WriteDiagnostics(diags)
+}
+
+type jsonDiagnostic struct {
+	Severity string     `json:"severity"`
+	Summary  string     `json:"summary"`
+	Detail   string     `json:"detail,omitempty"`
+	Range    *jsonRange `json:"range,omitempty"`
+}
+
+// jsonRange is a source range in the form of Terraform's JSON output.
+type jsonRange struct {
+	Filename string  `json:"filename"`
+	Start    jsonPos `json:"start"`
+	End      jsonPos `json:"end"`
+}
+
+type jsonPos struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Byte   int `json:"byte"`
+}
+
+// WriteJSON writes diagnostics as a JSON array whose ranges hold the
+// file name and the line, column and byte offset of their start and end.
+func WriteJSON(diags hcl.Diagnostics, w io.Writer) error {
+	result := make([]jsonDiagnostic, 0, len(diags))
+	for _, diag := range diags {
+		d := jsonDiagnostic{Severity: "error", Summary: diag.Summary, Detail: diag.Detail}
+		if diag.Severity == hcl.DiagWarning {
+			d.Severity = "warning"
+		}
+		if r := diag.Subject; r != nil {
+			d.Range = &jsonRange{
+				Filename: r.Filename,
+				Start:    jsonPos{Line: r.Start.Line, Column: r.Start.Column, Byte: r.Start.Byte},
+				End:      jsonPos{Line: r.End.Line, Column: r.End.Column, Byte: r.End.Byte},
+			}
+		}
+		result = append(result, d)
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,8 @@
+resource "test_thing" "a" {
+  name  = "a"
+  ports = [80, 443, 8080]
+
+  rule {}
+  rule {}
+  rule {}
+}
@@ -0,0 +1,6 @@
+provider "test" {
+}
+
+resource "test_thing" "a" {
+  ports = []
+}
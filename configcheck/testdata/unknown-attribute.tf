@@ -0,0 +1,8 @@
+resource "test_thing" "a" {
+  name   = "a"
+  colour = "red"
+
+  rule {
+    form = 1
+  }
+}
@@ -0,0 +1,9 @@
+resource "test_thing" "a" {
+  name = "a"
+
+  rule {}
+
+  settings {
+    verbose = true
+  }
+}
@@ -0,0 +1,12 @@
+provider "test" {
+  region = "eu-west-1"
+}
+
+resource "test_thing" "a" {
+  name  = "a"
+  ports = [80, 443]
+
+  rule {
+    from = 1
+  }
+}
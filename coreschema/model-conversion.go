@@ -0,0 +1,311 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package coreschema
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/evan-cleary/tf-schema-extractor/model"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// deprecatedMessage stands in for the deprecation message, which the
+// core schema reduces to a flag.
+const deprecatedMessage = "Deprecated"
+
+// ToModel maps the schema of one provider onto the extractor's model.
+// The core schema can express things the model cannot, such as object
+// types or map-nested blocks; those are mapped onto the closest model
+// equivalent and described in the returned problems, as are null
+// schemas, which are mapped onto empty ones.
+func ToModel(ps *ProviderSchema, pi *model.ProviderInfo, sdkType string) (*model.ResourceProviderSchema, []string) {
+	c := new(converter)
+	result := model.NewResourceProviderSchema(pi, sdkType)
+	if ps == nil {
+		c.report("provider", "null provider schema; exported as an empty provider")
+		return result, c.problems
+	}
+
+	if ps.Provider != nil {
+		result.Provider = c.block("provider", ps.Provider.Block)
+	}
+	if ps.ProviderMeta != nil && ps.ProviderMeta.Block != nil {
+		providerMeta := model.SingleBlock(c.block("provider_meta", ps.ProviderMeta.Block))
+		result.ProviderHeader.ProviderMeta = &providerMeta
+	}
+	for _, name := range sortedSchemas(ps.ResourceSchemas) {
+		s := ps.ResourceSchemas[name]
+		if s == nil {
+			c.report(name, "null schema; exported as an empty resource")
+			s = new(Schema)
+		}
+		result.Resources[name] = model.SchemaInfoWithTimeouts(c.block(name, s.Block))
+		result.ResourceHeaders[name] = header(s)
+	}
+	for _, name := range sortedSchemas(ps.DataSourceSchemas) {
+		s := ps.DataSourceSchemas[name]
+		if s == nil {
+			c.report("data."+name, "null schema; exported as an empty data source")
+			s = new(Schema)
+		}
+		result.DataSources[name] = model.SchemaInfoWithTimeouts(c.block("data."+name, s.Block))
+		h := header(s)
+		h.Operations = []string{model.OperationRead}
+		h.ReadOnly = true
+		result.DataSourceHeaders[name] = h
+	}
+	return result, c.problems
+}
+
+type converter struct {
+	problems []string
+}
+
+func (c *converter) report(path, format string, a ...interface{}) {
+	c.problems = append(c.problems, path+": "+fmt.Sprintf(format, a...))
+}
+
+// header fills what the core schema knows of a resource's header; the
+// operations it implements are not part of it.
+func header(s *Schema) model.ResourceHeader {
+	h := model.ResourceHeader{SchemaVersion: int(s.Version)}
+	if s.Block != nil {
+		h.Description = s.Block.Description
+		if h.Description != "" {
+			h.DescriptionKind = descriptionKind(s.Block.DescriptionKind)
+		}
+		if s.Block.Deprecated {
+			h.DeprecationMessage = deprecatedMessage
+		}
+	}
+	return h
+}
+
+func (c *converter) block(path string, b *Block) model.SchemaInfo {
+	result := make(model.SchemaInfo)
+	if b == nil {
+		return result
+	}
+	c.attributes(path, b.Attributes, result)
+	for _, name := range sortedBlockTypes(b.BlockTypes) {
+		bt := b.BlockTypes[name]
+		if bt == nil {
+			c.report(path+"."+name, "null block; dropped")
+			continue
+		}
+		if _, ok := result[name]; ok {
+			c.report(path+"."+name, "both an attribute and a block; the block is dropped")
+			continue
+		}
+		result[name] = c.blockType(path+"."+name, bt)
+	}
+	return result
+}
+
+// attributes maps attributes into info.
+func (c *converter) attributes(path string, attributes map[string]*Attribute, info model.SchemaInfo) {
+	for _, name := range sortedAttributes(attributes) {
+		if attributes[name] == nil {
+			c.report(path+"."+name, "null attribute; dropped")
+			continue
+		}
+		info[name] = c.attribute(path+"."+name, attributes[name])
+	}
+}
+
+func (c *converter) attribute(path string, a *Attribute) model.SchemaDefinition {
+	d := model.SchemaDefinition{
+		Optional:    a.Optional,
+		Required:    a.Required,
+		Computed:    a.Computed,
+		Sensitive:   a.Sensitive,
+		Description: a.Description,
+	}
+	if a.Description != "" {
+		d.DescriptionKind = descriptionKind(a.DescriptionKind)
+	}
+	if a.Deprecated {
+		d.Deprecated = deprecatedMessage
+	}
+
+	if a.NestedType != nil {
+		c.nestedType(path, &d, a.NestedType)
+		return d
+	}
+	var t cty.Type
+	if err := t.UnmarshalJSON(a.AttributeType); err != nil {
+		c.report(path, "invalid type %s: %s", a.AttributeType, err)
+		d.Type = "String"
+		return d
+	}
+	c.valueType(path, &d, t)
+	return d
+}
+
+// valueType sets the type and element type of d from t.
+func (c *converter) valueType(path string, d *model.SchemaDefinition, t cty.Type) {
+	switch {
+	case t == cty.String:
+		d.Type = "String"
+	case t == cty.Bool:
+		d.Type = "Bool"
+	case t == cty.Number:
+		// Integers and floats are both numbers to Terraform
+		d.Type = "Float"
+	case t.IsListType(), t.IsSetType(), t.IsMapType():
+		switch {
+		case t.IsListType():
+			d.Type = "List"
+		case t.IsSetType():
+			d.Type = "Set"
+		default:
+			d.Type = "Map"
+		}
+		d.ConfigImplicitMode = "Attr"
+		elem := model.SchemaDefinition{}
+		c.valueType(path+".*", &elem, t.ElementType())
+		d.Elem = &model.SchemaElement{Type: "SchemaElements", ElementsType: elem.Type, Elements: &elem}
+	case t.IsObjectType():
+		c.report(path, "object type %s has no equivalent; exported as a map of its attributes", t.FriendlyName())
+		d.Type = "Map"
+		d.ConfigImplicitMode = "Attr"
+		info := make(model.SchemaInfo)
+		attributeTypes := t.AttributeTypes()
+		names := make([]string, 0, len(attributeTypes))
+		for name := range attributeTypes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			at := attributeTypes[name]
+			attr := model.SchemaDefinition{Optional: t.AttributeOptional(name), Required: !t.AttributeOptional(name)}
+			c.valueType(path+"."+name, &attr, at)
+			info[name] = attr
+		}
+		d.Elem = &model.SchemaElement{Type: "SchemaInfo", Info: info}
+	case t.IsTupleType():
+		c.report(path, "tuple type %s has no equivalent; exported as a list", t.FriendlyName())
+		d.Type = "List"
+		d.ConfigImplicitMode = "Attr"
+	default:
+		c.report(path, "type %s has no equivalent; exported as a string", t.FriendlyName())
+		d.Type = "String"
+	}
+}
+
+func (c *converter) nestedType(path string, d *model.SchemaDefinition, nt *NestedType) {
+	info := make(model.SchemaInfo)
+	c.attributes(path, nt.Attributes, info)
+	d.ConfigImplicitMode = "Attr"
+	d.Elem = &model.SchemaElement{Type: "SchemaInfo", Info: info}
+	d.MinItems = int(nt.MinItems)
+	d.MaxItems = int(nt.MaxItems)
+	switch nt.NestingMode {
+	case NestingSingle:
+		d.Type = "List"
+		d.MaxItems = 1
+		d.Nesting = model.NestingSingle
+	case NestingList:
+		d.Type = "List"
+	case NestingSet:
+		d.Type = "Set"
+	case NestingMap:
+		d.Type = "Map"
+		d.Nesting = model.NestingMap
+	default:
+		c.report(path, "unknown nesting mode %q; exported as a list", nt.NestingMode)
+		d.Type = "List"
+	}
+}
+
+func (c *converter) blockType(path string, bt *BlockType) model.SchemaDefinition {
+	d := model.SchemaDefinition{
+		IsBlock:            true,
+		ConfigImplicitMode: "Block",
+		MinItems:           int(bt.MinItems),
+		MaxItems:           int(bt.MaxItems),
+		Elem:               &model.SchemaElement{Type: "SchemaInfo", Info: c.block(path, bt.Block)},
+	}
+	d.Required = bt.MinItems > 0
+	d.Optional = !d.Required
+	if bt.Block != nil {
+		d.Description = bt.Block.Description
+		if d.Description != "" {
+			d.DescriptionKind = descriptionKind(bt.Block.DescriptionKind)
+		}
+		if bt.Block.Deprecated {
+			d.Deprecated = deprecatedMessage
+		}
+	}
+
+	switch bt.NestingMode {
+	case NestingSingle:
+		d.Type = "List"
+		d.MaxItems = 1
+		d.Nesting = model.NestingSingle
+	case NestingGroup:
+		c.report(path, "group nesting has no equivalent; exported as a single block")
+		d.Type = "List"
+		d.MaxItems = 1
+	case NestingList:
+		d.Type = "List"
+	case NestingSet:
+		d.Type = "Set"
+	case NestingMap:
+		c.report(path, "map nesting has no equivalent; exported as a map of blocks")
+		d.Type = "Map"
+	default:
+		c.report(path, "unknown nesting mode %q; exported as a list", bt.NestingMode)
+		d.Type = "List"
+	}
+	return d
+}
+
+func descriptionKind(kind string) string {
+	if kind == DescriptionMarkdown {
+		return "markdown"
+	}
+	return "plain"
+}
+
+// sortedSchemas orders names so that problems are reported stably.
+func sortedSchemas(schemas map[string]*Schema) []string {
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedAttributes(attributes map[string]*Attribute) []string {
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedBlockTypes(blockTypes map[string]*BlockType) []string {
+	names := make([]string, 0, len(blockTypes))
+	for name := range blockTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,271 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package coreschema
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/evan-cleary/tf-schema-extractor/model"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FormatVersion is the format_version of the documents written by Write.
+const FormatVersion = "1.0"
+
+// ProviderAddress returns the source address Terraform would print for
+// the provider called name. The model does not record the namespace, so
+// that of the official providers is assumed.
+func ProviderAddress(name string) string {
+	return "registry.terraform.io/hashicorp/" + name
+}
+
+// Write writes s as a "terraform providers schema -json" document that
+// holds the single provider s, and returns the problems of converting it,
+// see FromModel.
+func Write(s *model.ResourceProviderSchema, w io.Writer) ([]string, error) {
+	ps, problems := FromModel(s)
+	doc := ProviderSchemas{
+		FormatVersion: FormatVersion,
+		Schemas:       map[string]*ProviderSchema{ProviderAddress(s.Name): ps},
+	}
+	return problems, json.NewEncoder(w).Encode(doc)
+}
+
+// FromModel maps an exported schema onto the schema Terraform core sees,
+// following the rules of helper/schema's CoreConfigSchema: blocks that
+// are computed but not optional become attributes, and resources of
+// helper/schema based providers get their implicit "id" attribute.
+// Defaults, validations and other constraints that Terraform core does
+// not know about are dropped. Types the core schema cannot express are
+// described in the returned problems.
+func FromModel(s *model.ResourceProviderSchema) (*ProviderSchema, []string) {
+	c := new(converter)
+	result := &ProviderSchema{
+		Provider:          &Schema{Block: c.toBlock("provider", s.Provider)},
+		ResourceSchemas:   make(map[string]*Schema),
+		DataSourceSchemas: make(map[string]*Schema),
+	}
+	if s.ProviderHeader.ProviderMeta != nil && s.ProviderHeader.ProviderMeta.Elem != nil {
+		result.ProviderMeta = &Schema{Block: c.toBlock("provider_meta", s.ProviderHeader.ProviderMeta.Elem.Info)}
+	}
+	implicitID := s.SDKType == "" || s.SDKType == model.SDKTypeSDK || s.SDKType == model.SDKTypeSDK2
+	for _, name := range sortedResources(s.Resources) {
+		result.ResourceSchemas[name] = c.toSchema(name, s.Resources[name], s.ResourceHeaders[name], implicitID)
+	}
+	for _, name := range sortedResources(s.DataSources) {
+		result.DataSourceSchemas[name] = c.toSchema("data."+name, s.DataSources[name], s.DataSourceHeaders[name], implicitID)
+	}
+	return result, c.problems
+}
+
+func (c *converter) toSchema(path string, info model.SchemaInfoWithTimeouts, h model.ResourceHeader, implicitID bool) *Schema {
+	b := c.toBlock(path, model.SchemaInfo(info))
+	if _, ok := info["id"]; implicitID && !ok {
+		b.Attributes["id"] = &Attribute{
+			AttributeType:   typeJSON(cty.String),
+			DescriptionKind: DescriptionPlain,
+			Optional:        true,
+			Computed:        true,
+		}
+	}
+	// The SDK adds its timeouts as a single block
+	if bt, ok := b.BlockTypes[model.TimeoutsConfigKey]; ok && bt.MaxItems == 1 {
+		bt.NestingMode = NestingSingle
+		bt.MaxItems = 0
+	}
+	b.Description = h.Description
+	b.DescriptionKind = toDescriptionKind(h.DescriptionKind)
+	b.Deprecated = h.DeprecationMessage != ""
+	return &Schema{Version: uint64(h.SchemaVersion), Block: b}
+}
+
+func (c *converter) toBlock(path string, info model.SchemaInfo) *Block {
+	b := &Block{
+		Attributes:      make(map[string]*Attribute),
+		BlockTypes:      make(map[string]*BlockType),
+		DescriptionKind: DescriptionPlain,
+	}
+	for _, name := range sortedInfo(info) {
+		d := info[name]
+		if isNestedBlock(d) {
+			b.BlockTypes[name] = c.toBlockType(path+"."+name, d)
+		} else {
+			b.Attributes[name] = c.toAttribute(path+"."+name, d)
+		}
+	}
+	return b
+}
+
+// isNestedBlock reports whether d is configured as a block. Blocks that
+// cannot be configured are attributes to Terraform core.
+func isNestedBlock(d model.SchemaDefinition) bool {
+	return d.IsBlock && d.Elem != nil && d.Elem.Type == "SchemaInfo" && !(d.Computed && !d.Optional)
+}
+
+func (c *converter) toBlockType(path string, d model.SchemaDefinition) *BlockType {
+	bt := &BlockType{
+		NestingMode: NestingList,
+		Block:       c.toBlock(path, d.Elem.Info),
+		MinItems:    uint64(d.MinItems),
+		MaxItems:    uint64(d.MaxItems),
+	}
+	if d.Nesting == model.NestingSingle {
+		bt.NestingMode = NestingSingle
+		bt.MaxItems = 0
+	} else if d.Type == "Set" {
+		bt.NestingMode = NestingSet
+	} else if d.Type != "List" {
+		c.report(path, "block of type %s has no equivalent; exported as a list", d.Type)
+	}
+	if d.Required && bt.MinItems == 0 {
+		bt.MinItems = 1
+	}
+	if d.Optional {
+		bt.MinItems = 0
+	}
+	bt.Block.Description = d.Description
+	bt.Block.DescriptionKind = toDescriptionKind(d.DescriptionKind)
+	bt.Block.Deprecated = d.Deprecated != ""
+	return bt
+}
+
+func (c *converter) toAttribute(path string, d model.SchemaDefinition) *Attribute {
+	a := &Attribute{
+		Description:     d.Description,
+		DescriptionKind: toDescriptionKind(d.DescriptionKind),
+		Deprecated:      d.Deprecated != "",
+		Required:        d.Required,
+		Optional:        d.Optional,
+		Computed:        d.Computed,
+		Sensitive:       d.Sensitive,
+	}
+	if d.Nesting == model.NestingMap && d.Elem != nil && d.Elem.Type == "SchemaInfo" {
+		a.NestedType = c.toNestedType(path, d)
+	} else {
+		a.AttributeType = typeJSON(c.toType(path, d))
+	}
+	return a
+}
+
+// toNestedType returns the nested type of a map-nested attribute, which
+// only protocol 6 providers declare. Without it, the map would become
+// an object type.
+func (c *converter) toNestedType(path string, d model.SchemaDefinition) *NestedType {
+	nt := &NestedType{
+		Attributes:  make(map[string]*Attribute),
+		NestingMode: NestingMap,
+		MinItems:    uint64(d.MinItems),
+		MaxItems:    uint64(d.MaxItems),
+	}
+	for _, name := range sortedInfo(d.Elem.Info) {
+		nt.Attributes[name] = c.toAttribute(path+"."+name, d.Elem.Info[name])
+	}
+	return nt
+}
+
+// toType returns the cty type of d. A map whose elements are a nested
+// schema is what ToModel makes of object types, and becomes one again,
+// as do single nested attributes.
+// Terraform core has no recursive types, so collections that refer back
+// to a schema further up become dynamic.
+func (c *converter) toType(path string, d model.SchemaDefinition) cty.Type {
+	switch d.Type {
+	case "String":
+		return cty.String
+	case "Bool":
+		return cty.Bool
+	case "Int", "Float":
+		return cty.Number
+	case "List", "Set", "Map":
+	default:
+		c.report(path, "type %q has no equivalent; exported as a string", d.Type)
+		return cty.String
+	}
+
+	if d.Elem != nil && d.Elem.Type == model.ElementTypeBackRef {
+		c.report(path, "recursive schema referring back to %s has no equivalent; exported as a dynamic value", d.Elem.Ref)
+		return cty.DynamicPseudoType
+	}
+	elem := c.toElemType(path, d.Elem)
+	if d.Elem != nil && d.Elem.Type == "SchemaInfo" && (d.Type == "Map" || d.Nesting == model.NestingSingle) {
+		return elem
+	}
+	switch d.Type {
+	case "List":
+		return cty.List(elem)
+	case "Set":
+		return cty.Set(elem)
+	}
+	return cty.Map(elem)
+}
+
+// toElemType returns the element type of a collection. Like the SDK,
+// collections without an element type hold strings.
+func (c *converter) toElemType(path string, e *model.SchemaElement) cty.Type {
+	switch {
+	case e == nil:
+		return cty.String
+	case e.Elements != nil:
+		return c.toType(path+".*", *e.Elements)
+	case e.Type == "SchemaInfo":
+		attributeTypes := make(map[string]cty.Type)
+		for _, name := range sortedInfo(e.Info) {
+			attributeTypes[name] = c.toType(path+"."+name, e.Info[name])
+		}
+		return cty.Object(attributeTypes)
+	case e.Value != "":
+		return c.toType(path+".*", model.SchemaDefinition{Type: e.Value})
+	case e.ElementsType != "":
+		return c.toType(path+".*", model.SchemaDefinition{Type: e.ElementsType})
+	}
+	c.report(path, "element of type %q has no equivalent; exported as a string", e.Type)
+	return cty.String
+}
+
+func toDescriptionKind(kind string) string {
+	if kind == "markdown" {
+		return DescriptionMarkdown
+	}
+	return DescriptionPlain
+}
+
+func typeJSON(t cty.Type) json.RawMessage {
+	result, err := t.MarshalJSON()
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+func sortedResources(resources map[string]model.SchemaInfoWithTimeouts) []string {
+	names := make([]string, 0, len(resources))
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedInfo(info model.SchemaInfo) []string {
+	names := make([]string, 0, len(info))
+	for name := range info {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
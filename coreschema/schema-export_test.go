@@ -0,0 +1,85 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package coreschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/evan-cleary/tf-schema-extractor/model"
+)
+
+// roundTripFixture is a protocol 6 provider schema that converts to the
+// model and back without loss.
+const roundTripFixture = `{
+	"provider": {"version": 0, "block": {
+		"attributes": {"region": {"type": "string", "description_kind": "plain", "required": true}},
+		"description_kind": "plain"
+	}},
+	"resource_schemas": {"test_thing": {"version": 1, "block": {
+		"attributes": {
+			"id": {"type": "string", "description_kind": "plain", "computed": true},
+			"listeners": {"nested_type": {"nesting_mode": "map", "attributes": {
+				"port": {"type": "number", "description_kind": "plain", "required": true},
+				"protocol": {"type": "string", "description_kind": "plain", "optional": true}
+			}}, "description_kind": "plain", "optional": true},
+			"origin": {"type": ["object", {"host": "string"}], "description_kind": "plain", "optional": true},
+			"tags": {"type": ["map", "string"], "description_kind": "plain", "optional": true}
+		},
+		"block_types": {"rule": {"nesting_mode": "set", "max_items": 3, "block": {
+			"attributes": {"to": {"type": "number", "description_kind": "plain", "optional": true}},
+			"description_kind": "plain"
+		}}},
+		"description_kind": "plain"
+	}}}
+}`
+
+func TestRoundTrip(t *testing.T) {
+	var ps ProviderSchema
+	if err := json.Unmarshal([]byte(roundTripFixture), &ps); err != nil {
+		t.Fatal(err)
+	}
+	s, problems := ToModel(&ps, &model.ProviderInfo{Name: "test"}, model.SDKTypePlugin)
+	if want := []string{"test_thing.origin: object type object has no equivalent; exported as a map of its attributes"}; !reflect.DeepEqual(problems, want) {
+		t.Errorf("ToModel() problems = %q, want %q", problems, want)
+	}
+	if got := s.Resources["test_thing"]["listeners"].Nesting; got != model.NestingMap {
+		t.Errorf("listeners nesting = %q, want %q", got, model.NestingMap)
+	}
+	if got := s.Resources["test_thing"]["origin"].Nesting; got != "" {
+		t.Errorf("origin nesting = %q, want none", got)
+	}
+
+	result, problems := FromModel(s)
+	if len(problems) > 0 {
+		t.Errorf("FromModel() problems = %q", problems)
+	}
+	got, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var g, w interface{}
+	if err = json.Unmarshal(got, &g); err != nil {
+		t.Fatal(err)
+	}
+	if err = json.Unmarshal([]byte(roundTripFixture), &w); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("FromModel(ToModel()) =\n%s\nwant\n%s", got, roundTripFixture)
+	}
+}
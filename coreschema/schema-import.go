@@ -0,0 +1,79 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package coreschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/evan-cleary/tf-schema-extractor/model"
+)
+
+// Load reads the document printed by "terraform providers schema -json".
+// Documents of a newer major format version are rejected, since their
+// structure may have changed incompatibly.
+func Load(r io.Reader) (*ProviderSchemas, error) {
+	result := new(ProviderSchemas)
+	if err := json.NewDecoder(r).Decode(result); err != nil {
+		return nil, err
+	}
+	if result.FormatVersion == "" {
+		return nil, fmt.Errorf("missing format_version, not a provider schemas document")
+	}
+	if major := strings.SplitN(result.FormatVersion, ".", 2)[0]; major != "0" && major != "1" {
+		return nil, fmt.Errorf("unsupported format_version %q", result.FormatVersion)
+	}
+	return result, nil
+}
+
+// Import maps every provider in doc onto the extractor's model, keyed
+// by the provider's source address. The document records neither the
+// versions of the providers nor their SDKs. Problems are prefixed with
+// the address of the provider they were found in.
+func Import(doc *ProviderSchemas) (map[string]*model.ResourceProviderSchema, []string) {
+	result := make(map[string]*model.ResourceProviderSchema)
+	var problems []string
+	for _, address := range Addresses(doc) {
+		pi := &model.ProviderInfo{Name: ProviderName(address)}
+		s, found := ToModel(doc.Schemas[address], pi, model.SDKTypeProvidersSchema)
+		for _, problem := range found {
+			problems = append(problems, address+": "+problem)
+		}
+		result[address] = s
+	}
+	return result, problems
+}
+
+// Addresses returns the source addresses of the providers in doc in
+// sorted order.
+func Addresses(doc *ProviderSchemas) []string {
+	addresses := make([]string, 0, len(doc.Schemas))
+	for address := range doc.Schemas {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// ProviderName returns the type of the provider at a source address,
+// e.g. "aws" for "registry.terraform.io/hashicorp/aws". Terraform 0.12
+// printed the bare type, which is returned unchanged.
+func ProviderName(address string) string {
+	return address[strings.LastIndex(address, "/")+1:]
+}
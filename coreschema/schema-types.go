@@ -0,0 +1,95 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package coreschema
+
+import "encoding/json"
+
+// The types below mirror the document printed by
+// "terraform providers schema -json", which is also how Terraform core
+// sees a provider's schema over the plugin protocol.
+
+// ProviderSchemas is the top-level document, keyed by provider source
+// address such as "registry.terraform.io/hashicorp/aws".
+type ProviderSchemas struct {
+	FormatVersion string                     `json:"format_version"`
+	Schemas       map[string]*ProviderSchema `json:"provider_schemas,omitempty"`
+}
+
+type ProviderSchema struct {
+	Provider          *Schema            `json:"provider,omitempty"`
+	ResourceSchemas   map[string]*Schema `json:"resource_schemas,omitempty"`
+	DataSourceSchemas map[string]*Schema `json:"data_source_schemas,omitempty"`
+	// Only available over the plugin protocol; Terraform does not print it
+	ProviderMeta *Schema `json:"-"`
+}
+
+type Schema struct {
+	Version uint64 `json:"version"`
+	Block   *Block `json:"block,omitempty"`
+}
+
+type Block struct {
+	Attributes      map[string]*Attribute `json:"attributes,omitempty"`
+	BlockTypes      map[string]*BlockType `json:"block_types,omitempty"`
+	Description     string                `json:"description,omitempty"`
+	DescriptionKind string                `json:"description_kind,omitempty"`
+	Deprecated      bool                  `json:"deprecated,omitempty"`
+}
+
+type Attribute struct {
+	// A cty type in its JSON form, e.g. "string" or ["list","number"];
+	// unset if NestedType is
+	AttributeType json.RawMessage `json:"type,omitempty"`
+	// Set for nested attributes of protocol 6 providers
+	NestedType      *NestedType `json:"nested_type,omitempty"`
+	Description     string      `json:"description,omitempty"`
+	DescriptionKind string      `json:"description_kind,omitempty"`
+	Deprecated      bool        `json:"deprecated,omitempty"`
+	Required        bool        `json:"required,omitempty"`
+	Optional        bool        `json:"optional,omitempty"`
+	Computed        bool        `json:"computed,omitempty"`
+	Sensitive       bool        `json:"sensitive,omitempty"`
+}
+
+type NestedType struct {
+	Attributes  map[string]*Attribute `json:"attributes,omitempty"`
+	NestingMode string                `json:"nesting_mode,omitempty"`
+	MinItems    uint64                `json:"min_items,omitempty"`
+	MaxItems    uint64                `json:"max_items,omitempty"`
+}
+
+type BlockType struct {
+	NestingMode string `json:"nesting_mode,omitempty"`
+	Block       *Block `json:"block,omitempty"`
+	MinItems    uint64 `json:"min_items,omitempty"`
+	MaxItems    uint64 `json:"max_items,omitempty"`
+}
+
+// Values of BlockType.NestingMode and NestedType.NestingMode. Nested
+// attributes do not use NestingGroup.
+const (
+	NestingSingle = "single"
+	NestingGroup  = "group"
+	NestingList   = "list"
+	NestingSet    = "set"
+	NestingMap    = "map"
+)
+
+// Values of DescriptionKind.
+const (
+	DescriptionPlain    = "plain"
+	DescriptionMarkdown = "markdown"
+)
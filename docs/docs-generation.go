@@ -0,0 +1,412 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package docs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/evan-cleary/tf-schema-extractor/model"
+)
+
+// Options configure the generator.
+type Options struct {
+	// Directory holding templates that replace the built-in ones, laid
+	// out like the generated files: "index.md.tmpl", "resources.md.tmpl"
+	// for every resource, "resources/<name>.md.tmpl" for a single one,
+	// and likewise for data sources. Missing templates fall back to the
+	// more general ones.
+	TemplateDir string
+}
+
+// Page is what templates are executed with.
+type Page struct {
+	ProviderName string
+	// Name of the resource or data source; empty for the index
+	Name string
+	// "Resource" or "Data Source"
+	Kind        string
+	Description string
+	Deprecation string
+	Importable  bool
+
+	// Markdown rendered from the schema. Arguments lists what can be
+	// configured, Attributes what is only exported, NestedBlocks the
+	// sections for nested blocks and Timeouts the "timeouts" block.
+	Arguments    string
+	Attributes   string
+	NestedBlocks string
+	Timeouts     string
+
+	// For templates that render their own sections
+	Schema model.SchemaInfo
+	Header model.ResourceHeader
+}
+
+const (
+	kindResource   = "Resource"
+	kindDataSource = "Data Source"
+)
+
+// Generate writes the documentation of s to dir in the layout of the
+// Terraform Registry: "index.md", "resources/<name>.md" and
+// "data-sources/<name>.md", where name lacks the provider prefix.
+func Generate(s *model.ResourceProviderSchema, dir string, options Options) error {
+	files, err := Render(s, options)
+	if err != nil {
+		return err
+	}
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		file := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(file, files[path], 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Render returns the contents of the files Generate writes, keyed by
+// their slash-separated path relative to the docs directory.
+func Render(s *model.ResourceProviderSchema, options Options) (map[string][]byte, error) {
+	result := make(map[string][]byte)
+
+	index := &Page{ProviderName: s.Name, Schema: s.Provider}
+	r := &renderer{}
+	index.Arguments = r.arguments(s.Provider, "")
+	index.NestedBlocks = r.nestedBlocks()
+	content, err := options.execute(index, "index.md.tmpl", "", indexTemplate)
+	if err != nil {
+		return nil, err
+	}
+	result["index.md"] = content
+
+	for _, group := range []struct {
+		dir     string
+		kind    string
+		schemas map[string]model.SchemaInfoWithTimeouts
+		headers map[string]model.ResourceHeader
+	}{
+		{"resources", kindResource, s.Resources, s.ResourceHeaders},
+		{"data-sources", kindDataSource, s.DataSources, s.DataSourceHeaders},
+	} {
+		for name, info := range group.schemas {
+			page := newPage(s.Name, name, group.kind, model.SchemaInfo(info), group.headers[name])
+			shortName := strings.TrimPrefix(name, s.Name+"_")
+			content, err := options.execute(page, group.dir+".md.tmpl", group.dir+"/"+shortName+".md.tmpl", resourceTemplate)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", name, err)
+			}
+			result[group.dir+"/"+shortName+".md"] = content
+		}
+	}
+	return result, nil
+}
+
+func newPage(providerName, name, kind string, info model.SchemaInfo, header model.ResourceHeader) *Page {
+	page := &Page{
+		ProviderName: providerName,
+		Name:         name,
+		Kind:         kind,
+		Description:  header.Description,
+		Deprecation:  header.DeprecationMessage,
+		Importable:   header.Importable,
+		Schema:       info,
+		Header:       header,
+	}
+
+	arguments := make(model.SchemaInfo)
+	for k, d := range info {
+		if k == model.TimeoutsConfigKey && isBlock(d) {
+			page.Timeouts = timeouts(d.Elem.Info, kind)
+			continue
+		}
+		arguments[k] = d
+	}
+	r := &renderer{}
+	page.Arguments = r.arguments(arguments, "")
+	page.Attributes = r.attributes(arguments, "")
+	page.NestedBlocks = r.nestedBlocks()
+	return page
+}
+
+// execute renders page with the most specific template available.
+func (o Options) execute(page *Page, general, specific, builtin string) ([]byte, error) {
+	text := builtin
+	for _, name := range []string{specific, general} {
+		if name == "" || o.TemplateDir == "" {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(o.TemplateDir, filepath.FromSlash(name)))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+		break
+	}
+
+	t, err := template.New("page").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, page); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var templateFuncs = template.FuncMap{
+	"indent": func(n int, text string) string {
+		prefix := strings.Repeat(" ", n)
+		return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+	},
+	"firstLine": func(text string) string {
+		return strings.SplitN(text, "\n", 2)[0]
+	},
+}
+
+// renderer renders the argument lists of a block and collects the
+// sections of the nested blocks it encounters, in order.
+type renderer struct {
+	sections []string
+	// Paths of the nested blocks that have a section
+	documented map[string]bool
+}
+
+// arguments lists the attributes and blocks of info that can be
+// configured, required ones first.
+func (r *renderer) arguments(info model.SchemaInfo, path string) string {
+	var required, optional []string
+	for _, name := range sortedNames(info) {
+		d := info[name]
+		switch {
+		case d.Required:
+			required = append(required, r.item(name, d, path, "Required"))
+		case d.Optional:
+			optional = append(optional, r.item(name, d, path, "Optional"))
+		}
+	}
+	return strings.Join(append(required, optional...), "")
+}
+
+// attributes lists the attributes of info that are only exported.
+func (r *renderer) attributes(info model.SchemaInfo, path string) string {
+	var result []string
+	for _, name := range sortedNames(info) {
+		if d := info[name]; d.Computed && !d.Optional && !d.Required {
+			result = append(result, r.item(name, d, path, ""))
+		}
+	}
+	return strings.Join(result, "")
+}
+
+func (r *renderer) item(name string, d model.SchemaDefinition, path, qualifier string) string {
+	var parts []string
+	if qualifier != "" {
+		parts = append(parts, "("+qualifier+")")
+	}
+	if d.Description != "" {
+		parts = append(parts, sentence(d.Description))
+	}
+	if d.Elem != nil && d.Elem.Type == "SchemaInfo" {
+		nestedPath := join(path, name)
+		parts = append(parts, fmt.Sprintf("See [`%s`](#%s) below.", nestedPath, anchor(nestedPath)))
+		r.nested(nestedPath, d)
+	}
+	if d.Elem != nil && d.Elem.Type == model.ElementTypeBackRef {
+		parts = append(parts, r.recursive(d.Elem.Ref))
+	}
+	if d.IsBlock && d.MaxItems > 0 {
+		parts = append(parts, fmt.Sprintf("At most %d may be declared.", d.MaxItems))
+	}
+	if d.ForceNew {
+		parts = append(parts, "Changing this forces a new resource to be created.")
+	}
+	if d.Default != nil {
+		if d.Default.Source == model.DefaultSourceFunc {
+			parts = append(parts, "Default computed at runtime.")
+		} else {
+			parts = append(parts, fmt.Sprintf("Defaults to `%s`.", value(d.Default.Value)))
+		}
+	}
+	if d.Validation != nil && len(d.Validation.AllowedValues) > 0 {
+		values := make([]string, 0, len(d.Validation.AllowedValues))
+		for _, v := range d.Validation.AllowedValues {
+			values = append(values, "`"+value(v)+"`")
+		}
+		parts = append(parts, fmt.Sprintf("Possible values are %s.", strings.Join(values, ", ")))
+	}
+	if len(d.ConflictsWith) > 0 {
+		parts = append(parts, fmt.Sprintf("Conflicts with `%s`.", strings.Join(d.ConflictsWith, "`, `")))
+	}
+	if d.Deprecated != "" {
+		parts = append(parts, "**Deprecated:** "+sentence(d.Deprecated))
+	}
+
+	if len(parts) == 0 {
+		return fmt.Sprintf("* `%s`\n", name)
+	}
+	return fmt.Sprintf("* `%s` - %s\n", name, strings.Join(parts, " "))
+}
+
+// nested adds the section of the nested block or object at path.
+func (r *renderer) nested(path string, d model.SchemaDefinition) {
+	// Reserve the slot so that sections appear in the order of their
+	// first mention, parents before children.
+	i := len(r.sections)
+	r.sections = append(r.sections, "")
+	if r.documented == nil {
+		r.documented = make(map[string]bool)
+	}
+	r.documented[path] = true
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n<a id=\"%s\"></a>\n### Nested Schema for `%s`\n\n", anchor(path), path)
+	arguments := r.arguments(d.Elem.Info, path)
+	b.WriteString(arguments)
+	if attributes := r.attributes(d.Elem.Info, path); attributes != "" {
+		if arguments != "" {
+			b.WriteString("\nThe following attributes are exported:\n\n")
+		}
+		b.WriteString(attributes)
+	}
+	r.sections[i] = b.String()
+}
+
+// recursive describes an Elem that refers back to the schema at ref
+// further up, see model.ResolveBackRef.
+func (r *renderer) recursive(ref string) string {
+	segments := strings.Split(ref, ".")
+	if segments[0] == "data" && len(segments) > 1 {
+		segments = segments[1:]
+	}
+	var names []string
+	for _, segment := range segments[1:] {
+		if segment != "*" {
+			names = append(names, segment)
+		}
+	}
+	path := strings.Join(names, ".")
+	switch {
+	case path == "":
+		return "Nested recursively with the top-level schema."
+	case r.documented[path]:
+		return fmt.Sprintf("Nested recursively, see [`%s`](#%s).", path, anchor(path))
+	default:
+		return fmt.Sprintf("Nested recursively like `%s`.", path)
+	}
+}
+
+func (r *renderer) nestedBlocks() string {
+	return strings.Join(r.sections, "")
+}
+
+// timeouts lists the timeouts of a resource and their defaults.
+func timeouts(info model.SchemaInfo, kind string) string {
+	subject := "the resource"
+	if kind == kindDataSource {
+		subject = "the data source"
+	}
+	var b strings.Builder
+	for _, key := range model.TimeoutKeys() {
+		d, ok := info[key]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&b, "* `%s` -", key)
+		if d.Default != nil && d.Default.Source == model.DefaultSourceStatic {
+			fmt.Fprintf(&b, " (Defaults to %s)", value(d.Default.Value))
+		}
+		switch key {
+		case model.TimeoutCreate:
+			fmt.Fprintf(&b, " Used when creating %s.\n", subject)
+		case model.TimeoutRead:
+			fmt.Fprintf(&b, " Used when retrieving %s.\n", subject)
+		case model.TimeoutUpdate:
+			fmt.Fprintf(&b, " Used when updating %s.\n", subject)
+		case model.TimeoutDelete:
+			fmt.Fprintf(&b, " Used when deleting %s.\n", subject)
+		case model.TimeoutDefault:
+			b.WriteString(" Used for operations without a timeout of their own.\n")
+		}
+	}
+	return b.String()
+}
+
+func isBlock(d model.SchemaDefinition) bool {
+	return d.IsBlock && d.Elem != nil && d.Elem.Type == "SchemaInfo"
+}
+
+// value renders a default or allowed value; strings are rendered as is.
+func value(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+// sentence terminates text with a period unless it already ends a
+// sentence.
+func sentence(text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" || strings.HasSuffix(text, ".") || strings.HasSuffix(text, "!") || strings.HasSuffix(text, "?") {
+		return text
+	}
+	return text + "."
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// anchor returns the HTML anchor of the section of a nested block.
+func anchor(path string) string {
+	return "nestedblock--" + strings.ReplaceAll(path, ".", "--")
+}
+
+func sortedNames(info model.SchemaInfo) []string {
+	names := make([]string, 0, len(info))
+	for name := range info {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,70 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package docs
+
+// The built-in templates, used unless the template directory overrides
+// them. They are executed with a Page.
+
+const indexTemplate = `---
+page_title: "Provider: {{.ProviderName}}"
+description: |-
+  The {{.ProviderName}} provider.
+---
+
+# {{.ProviderName}} Provider
+{{if .Arguments}}
+## Argument Reference
+
+The following arguments are supported in the ` + "`" + `provider` + "`" + ` block:
+
+{{.Arguments}}{{end}}{{.NestedBlocks}}`
+
+const resourceTemplate = `---
+page_title: "{{.Name}} {{.Kind}} - terraform-provider-{{.ProviderName}}"
+subcategory: ""{{if .Description}}
+description: |-
+{{indent 2 (firstLine .Description)}}{{end}}
+---
+
+# {{.Name}} ({{.Kind}})
+{{if .Deprecation}}
+!> **Deprecated:** {{.Deprecation}}
+{{end}}{{if .Description}}
+{{.Description}}
+{{end}}{{if .Arguments}}
+## Argument Reference
+
+The following arguments are supported:
+
+{{.Arguments}}{{end}}{{if .Attributes}}
+## Attribute Reference
+
+In addition to all arguments above, the following attributes are exported:
+
+{{.Attributes}}{{end}}{{.NestedBlocks}}{{if .Timeouts}}
+## Timeouts
+
+The ` + "`" + `timeouts` + "`" + ` block allows you to specify [timeouts](https://www.terraform.io/docs/configuration/resources.html#timeouts) for certain actions:
+
+{{.Timeouts}}{{end}}{{if .Importable}}
+## Import
+
+` + "`" + `{{.Name}}` + "`" + ` can be imported using its ID, e.g.
+
+` + "```" + `shell
+terraform import {{.Name}}.example <id>
+` + "```" + `
+{{end}}`
@@ -17,102 +17,200 @@
 package extractor
 
 import (
+	"github.com/evan-cleary/tf-schema-extractor/model"
+	"github.com/evan-cleary/tf-schema-extractor/validators"
 	"github.com/hashicorp/terraform/helper/schema"
 
-	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
-	"reflect"
+	"sort"
 	"time"
 )
 
-type Extractor struct{}
+type Extractor struct {
+	// Number of resources and data sources exported concurrently; they
+	// are exported serially if it is less than 2
+	Workers int
+	// Write nested schemas inline instead of to a definitions table, see
+	// model.Encoder
+	Inline bool
+	// Maximum nesting depth of schemas, model.DefaultMaxDepth if 0
+	MaxDepth int
+}
 
 // ExportSchema should be called to export the structure
-// of the provider.
-func (e *Extractor) Export(p *schema.Provider, pi *ProviderInfo) *ResourceProviderSchema {
-	result := new(ResourceProviderSchema)
+// of the provider. It fails if the nested schemas of an attribute are
+// deeper than MaxDepth.
+func (e *Extractor) Export(p *schema.Provider, pi *ProviderInfo) (*ResourceProviderSchema, error) {
+	return e.exporter().Export(e.adapter(p), pi)
+}
 
-	result.SchemaVersion = "2"
-	result.Name = pi.Name
-	result.Type = "provider"
-	result.Version = pi.Revision
-	result.Provider = schemaMap(p.Schema).Export(e)
-	result.Resources = make(map[string]SchemaInfoWithTimeouts)
-	result.DataSources = make(map[string]SchemaInfoWithTimeouts)
+// Encode exports the structure of the provider like Export, but writes
+// each resource and data source to w as soon as it is exported, so that
+// only a few of them are held in memory at a time.
+func (e *Extractor) Encode(p *schema.Provider, pi *ProviderInfo, w io.Writer) error {
+	return e.exporter().Encode(e.adapter(p), pi, w)
+}
 
-	for k, r := range p.ResourcesMap {
-		result.Resources[k] = e.ExportResourceWithTimeouts(r)
-	}
-	for k, ds := range p.DataSourcesMap {
-		result.DataSources[k] = e.ExportResourceWithTimeouts(ds)
-	}
+func (e *Extractor) exporter() *model.Exporter {
+	return &model.Exporter{Workers: e.Workers, Inline: e.Inline, MaxDepth: e.MaxDepth}
+}
 
-	return result
+func (e *Extractor) adapter(p *schema.Provider) *providerAdapter {
+	return &providerAdapter{e, p}
 }
 
-func (e *Extractor) ExportResourceWithTimeouts(r *schema.Resource) SchemaInfoWithTimeouts {
-	var timeouts []string
-	t := r.Timeouts
-	if t != nil {
-		for _, key := range timeoutKeys() {
-			var timeout *time.Duration
-			switch key {
-			case TimeoutCreate:
-				timeout = t.Create
-			case TimeoutUpdate:
-				timeout = t.Update
-			case TimeoutRead:
-				timeout = t.Read
-			case TimeoutDelete:
-				timeout = t.Delete
-			case TimeoutDefault:
-				timeout = t.Default
-			default:
-				panic("Unsupported timeout key, update switch statement!")
-			}
-			if timeout != nil {
-				timeouts = append(timeouts, key)
-			}
-		}
+// ExportProviderHeader exports the provider-level metadata of p. It
+// fails like Export.
+func (e *Extractor) ExportProviderHeader(p *schema.Provider) (ProviderHeader, error) {
+	return e.exporter().ProviderHeader(e.adapter(p))
+}
+
+// ExportResourceWithTimeouts exports the schema of r including its
+// "timeouts" block. It fails like Export.
+func (e *Extractor) ExportResourceWithTimeouts(r *schema.Resource) (SchemaInfoWithTimeouts, error) {
+	return e.exporter().ExportResourceWithTimeouts(e.adapter(nil), r)
+}
+
+// ExportResourceHeader exports the resource-level metadata of r.
+func (e *Extractor) ExportResourceHeader(r *schema.Resource) ResourceHeader {
+	header := model.NewResourceHeader(r.Create != nil, r.Read != nil, r.Update != nil, r.Delete != nil)
+	header.DeprecationMessage = r.DeprecationMessage
+	header.SchemaVersion = r.SchemaVersion
+	for _, u := range r.StateUpgraders {
+		header.StateUpgraders = append(header.StateUpgraders, u.Version)
 	}
-	result := make(SchemaInfoWithTimeouts)
-	for nk, nv := range e.ExportResource(r) {
-		result[nk] = nv
+	header.Importable = r.Importer != nil
+	header.CustomizeDiff = r.CustomizeDiff != nil
+	return header
+}
+
+// ExportResource exports the schema of r. It fails like Export.
+func (e *Extractor) ExportResource(r *schema.Resource) (SchemaInfo, error) {
+	return e.exporter().ExportResource(e.adapter(nil), r)
+}
+
+// providerAdapter exports a provider through a model.Exporter.
+type providerAdapter struct {
+	e *Extractor
+	p *schema.Provider
+}
+
+func (a *providerAdapter) SDKType() string {
+	return ""
+}
+
+func (a *providerAdapter) ExportProvider(t *model.Trace) SchemaInfo {
+	return schemaMap(a.p.Schema).Export(a.e, t)
+}
+
+func (a *providerAdapter) ExportProviderMeta(t *model.Trace) SchemaInfo {
+	if len(a.p.ProviderMetaSchema) == 0 {
+		return nil
 	}
-	if len(timeouts) > 0 {
-		result["__timeouts__"] = timeouts
+	return schemaMap(a.p.ProviderMetaSchema).Export(a.e, t)
+}
+
+func (a *providerAdapter) ProviderHeader() ProviderHeader {
+	header := ProviderHeader{}
+	header.TerraformVersion = a.p.TerraformVersion
+	header.Configurable = a.p.ConfigureFunc != nil
+	return header
+}
+
+func (a *providerAdapter) Resources() map[string]interface{} {
+	return resources(a.p.ResourcesMap)
+}
+
+func (a *providerAdapter) DataSources() map[string]interface{} {
+	return resources(a.p.DataSourcesMap)
+}
+
+func resources(m map[string]*schema.Resource) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, r := range m {
+		result[k] = r
 	}
 	return result
 }
 
-func (e *Extractor) ExportResource(r *schema.Resource) SchemaInfo {
-	return schemaMap(r.Schema).Export(e)
+func (a *providerAdapter) ExportResource(r interface{}, t *model.Trace) SchemaInfo {
+	return schemaMap(r.(*schema.Resource).Schema).Export(a.e, t)
+}
+
+func (a *providerAdapter) ExportTimeouts(r interface{}) SchemaInfo {
+	if t := r.(*schema.Resource).Timeouts; t != nil {
+		return exportTimeouts(t)
+	}
+	return nil
+}
+
+func (a *providerAdapter) ResourceHeader(r interface{}) ResourceHeader {
+	return a.e.ExportResourceHeader(r.(*schema.Resource))
+}
+
+// exportTimeouts exports each configurable timeout of the resource as
+// an optional string attribute defaulting to its configured duration.
+func exportTimeouts(t *schema.ResourceTimeout) SchemaInfo {
+	result := make(SchemaInfo)
+	for _, key := range model.TimeoutKeys() {
+		var timeout *time.Duration
+		switch key {
+		case model.TimeoutCreate:
+			timeout = t.Create
+		case model.TimeoutUpdate:
+			timeout = t.Update
+		case model.TimeoutRead:
+			timeout = t.Read
+		case model.TimeoutDelete:
+			timeout = t.Delete
+		case model.TimeoutDefault:
+			timeout = t.Default
+		default:
+			panic("Unsupported timeout key, update switch statement!")
+		}
+		if timeout != nil {
+			result[key] = model.TimeoutAttribute(*timeout)
+		}
+	}
+	return result
 }
 
 // schemaMap is a wrapper that adds nice functions on top of schemas.
 type schemaMap map[string]*schema.Schema
 
-// Export exports the format of this schema.
-func (m schemaMap) Export(e *Extractor) SchemaInfo {
+// Export exports the format of this schema, in order of the attribute
+// names so that t fails at the same attribute every time.
+func (m schemaMap) Export(e *Extractor, t *model.Trace) SchemaInfo {
+	names := make([]string, 0, len(m))
+	for k := range m {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
 	result := make(SchemaInfo)
-	for k, v := range m {
-		item := e.export(v)
+	for _, k := range names {
+		t.Attribute(k, m[k])
+		item := e.export(m[k], t)
+		t.EndAttribute()
 		result[k] = item
 	}
 	return result
 }
 
-func (e *Extractor) export(v *schema.Schema) SchemaDefinition {
+func (e *Extractor) export(v *schema.Schema, t *model.Trace) SchemaDefinition {
 	item := SchemaDefinition{}
 
-	item.Type = shortenType(fmt.Sprintf("%s", v.Type))
+	item.Type = model.ShortenType(fmt.Sprintf("%s", v.Type))
 	item.Optional = v.Optional
 	item.Required = v.Required
 	item.Description = v.Description
 	item.InputDefault = v.InputDefault
 	item.Computed = v.Computed
+	item.ForceNew = v.ForceNew
+	item.Sensitive = v.Sensitive
 	item.MaxItems = v.MaxItems
 	item.MinItems = v.MinItems
 	item.PromoteSingle = v.PromoteSingle
@@ -134,28 +232,62 @@ func (e *Extractor) export(v *schema.Schema) SchemaDefinition {
 	}
 
 	if v.Elem != nil {
-		item.Elem = e.exportValue(v.Elem, fmt.Sprintf("%T", v.Elem))
+		item.Elem = e.exportValue(v.Elem, fmt.Sprintf("%T", v.Elem), t)
 	}
 
-	// TODO: Find better solution
-	if defValue, err := v.DefaultValue(); err == nil && defValue != nil && !reflect.DeepEqual(defValue, v.Default) {
-		item.Default = e.exportValue(defValue, fmt.Sprintf("%T", defValue))
+	item.Default = exportDefault(v)
+	if v.ValidateFunc != nil {
+		// Probing calls the provider's validator
+		t.Call(func() {
+			item.Validation = inspectValidateFunc(item.Type, v.ValidateFunc)
+		})
 	}
 	return item
 }
 
-func (e *Extractor) exportValue(value interface{}, t string) *SchemaElement {
+// inspectValidateFunc infers the constraints of a helper/validation
+// validator by probing it.
+func inspectValidateFunc(valueType string, f schema.SchemaValidateFunc) *validators.Constraints {
+	return validators.Inspect(validators.FuncName(f), valueType, func(value interface{}) []string {
+		var messages []string
+		_, errs := f(value, "probe")
+		for _, err := range errs {
+			messages = append(messages, err.Error())
+		}
+		return messages
+	})
+}
+
+// exportDefault reports the default of v. A DefaultFunc is never called:
+// its value depends on the environment of the extraction (and may be a
+// secret), so only its presence is recorded.
+func exportDefault(v *schema.Schema) *SchemaDefault {
+	if v.Default != nil {
+		return &SchemaDefault{Source: model.DefaultSourceStatic, Value: model.JSONValue(v.Default)}
+	}
+	if v.DefaultFunc == nil {
+		return nil
+	}
+	return &SchemaDefault{Source: model.DefaultSourceFunc}
+}
+
+// exportValue exports the Elem of the current attribute of trace.
+func (e *Extractor) exportValue(value interface{}, t string, trace *model.Trace) *SchemaElement {
 	s2, ok := value.(*schema.Schema)
 	if ok {
-		return &SchemaElement{Type: "SchemaElements", ElementsType: shortenType(fmt.Sprintf("%s", s2.Type))}
+		return trace.Elements(s2, func() SchemaDefinition {
+			return e.export(s2, trace)
+		})
 	}
 	r2, ok := value.(*schema.Resource)
 	if ok {
-		return &SchemaElement{Type: "SchemaInfo", Info: e.ExportResource(r2)}
+		return trace.Block(r2, func() SchemaInfo {
+			return schemaMap(r2.Schema).Export(e, trace)
+		})
 	}
 	vt, ok := value.(schema.ValueType)
 	if ok {
-		return &SchemaElement{Value: shortenType(fmt.Sprintf("%v", vt))}
+		return &SchemaElement{Value: model.ShortenType(fmt.Sprintf("%v", vt))}
 	}
 	// Unknown case
 	return &SchemaElement{Type: t, Value: fmt.Sprintf("%v", value)}
@@ -171,23 +303,7 @@ func (e *Extractor) Generate(provider *schema.Provider, pi *ProviderInfo, output
 }
 
 func (e *Extractor) DoGenerate(provider *schema.Provider, pi *ProviderInfo, outputFilePath string) error {
-	providerJson, err := json.MarshalIndent(e.Export(provider, pi), "", "  ")
-
-	if err != nil {
-		return err
-	}
-
-	file, err := os.Create(outputFilePath)
-	if err != nil {
-		return err
-	}
-
-	defer file.Close()
-
-	_, err = file.Write(providerJson)
-	if err != nil {
-		return err
-	}
-
-	return file.Sync()
+	return model.EncodeFile(outputFilePath, func(w io.Writer) error {
+		return e.Encode(provider, pi, w)
+	})
 }
@@ -0,0 +1,483 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package extractor
+
+import (
+	"github.com/evan-cleary/tf-schema-extractor/model"
+	"github.com/evan-cleary/tf-schema-extractor/validators"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FrameworkExtractor exports providers written with
+// terraform-plugin-framework.
+type FrameworkExtractor struct {
+	// Number of resources and data sources exported concurrently; they
+	// are exported serially if it is less than 2
+	Workers int
+	// Write nested schemas inline instead of to a definitions table, see
+	// model.Encoder
+	Inline bool
+	// Maximum nesting depth of schemas, model.DefaultMaxDepth if 0
+	MaxDepth int
+}
+
+// Export exports the structure of the provider. Errors reported by the
+// provider while building its schemas are returned, and it fails if the
+// nested schemas of an attribute are deeper than MaxDepth.
+func (m *FrameworkExtractor) Export(p tfsdk.Provider, pi *ProviderInfo) (*ResourceProviderSchema, error) {
+	a, err := m.adapter(p)
+	if err != nil {
+		return nil, err
+	}
+	return m.exporter().Export(a, pi)
+}
+
+// Encode exports the structure of the provider like Export, but writes
+// each resource and data source to w as soon as it is exported, so that
+// only a few of them are held in memory at a time.
+func (m *FrameworkExtractor) Encode(p tfsdk.Provider, pi *ProviderInfo, w io.Writer) error {
+	a, err := m.adapter(p)
+	if err != nil {
+		return err
+	}
+	return m.exporter().Encode(a, pi, w)
+}
+
+func (m *FrameworkExtractor) exporter() *model.Exporter {
+	return &model.Exporter{Workers: m.Workers, Inline: m.Inline, MaxDepth: m.MaxDepth}
+}
+
+// adapter builds every schema of the provider up front, since the
+// framework reports errors while doing so and the Exporter expects none.
+func (m *FrameworkExtractor) adapter(p tfsdk.Provider) (*providerAdapter, error) {
+	ctx := context.Background()
+	a := &providerAdapter{m: m}
+
+	var diags diag.Diagnostics
+	a.provider, diags = p.GetSchema(ctx)
+	if err := diagsError("provider", diags); err != nil {
+		return nil, err
+	}
+	var err error
+	if a.providerMeta, err = metaSchema(p); err != nil {
+		return nil, err
+	}
+
+	resources, diags := p.GetResources(ctx)
+	if err := diagsError("provider", diags); err != nil {
+		return nil, err
+	}
+	a.resources = make(map[string]interface{}, len(resources))
+	for _, k := range sortedResourceTypes(resources) {
+		s, diags := resources[k].GetSchema(ctx)
+		if err := diagsError(k, diags); err != nil {
+			return nil, err
+		}
+		// The Resource interface requires every operation
+		header := exportHeader(model.NewResourceHeader(true, true, true, true), s)
+		a.resources[k] = &resourceSchema{schema: s, header: header}
+	}
+
+	dataSources, diags := p.GetDataSources(ctx)
+	if err := diagsError("provider", diags); err != nil {
+		return nil, err
+	}
+	a.dataSources = make(map[string]interface{}, len(dataSources))
+	for _, k := range sortedDataSourceTypes(dataSources) {
+		s, diags := dataSources[k].GetSchema(ctx)
+		if err := diagsError(k, diags); err != nil {
+			return nil, err
+		}
+		header := exportHeader(model.NewResourceHeader(false, true, false, false), s)
+		a.dataSources[k] = &resourceSchema{schema: s, header: header}
+	}
+	return a, nil
+}
+
+// metaSchema returns the provider_meta schema of p, nil if it has none.
+func metaSchema(p tfsdk.Provider) (*tfsdk.Schema, error) {
+	pm, ok := p.(tfsdk.ProviderWithProviderMeta)
+	if !ok {
+		return nil, nil
+	}
+	s, diags := pm.GetMetaSchema(context.Background())
+	if err := diagsError("provider_meta", diags); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func sortedResourceTypes(resources map[string]tfsdk.ResourceType) []string {
+	names := make([]string, 0, len(resources))
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedDataSourceTypes(dataSources map[string]tfsdk.DataSourceType) []string {
+	names := make([]string, 0, len(dataSources))
+	for name := range dataSources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ExportProviderHeader exports the provider-level metadata of p. It
+// fails like Export.
+func (m *FrameworkExtractor) ExportProviderHeader(p tfsdk.Provider) (ProviderHeader, error) {
+	providerMeta, err := metaSchema(p)
+	if err != nil {
+		return ProviderHeader{}, err
+	}
+	return m.exporter().ProviderHeader(&providerAdapter{m: m, providerMeta: providerMeta})
+}
+
+// ExportSchema exports the attributes of a provider, resource or data
+// source schema. It fails like Export.
+func (m *FrameworkExtractor) ExportSchema(s tfsdk.Schema) (SchemaInfo, error) {
+	return m.exporter().ExportResource(&providerAdapter{m: m}, &resourceSchema{schema: s})
+}
+
+func exportHeader(header ResourceHeader, s tfsdk.Schema) ResourceHeader {
+	header.Description, header.DescriptionKind = description(s.Description, s.MarkdownDescription)
+	header.DeprecationMessage = s.DeprecationMessage
+	header.SchemaVersion = int(s.Version)
+	return header
+}
+
+// providerAdapter exports a provider through a model.Exporter.
+type providerAdapter struct {
+	m            *FrameworkExtractor
+	provider     tfsdk.Schema
+	providerMeta *tfsdk.Schema
+	// Of *resourceSchema
+	resources   map[string]interface{}
+	dataSources map[string]interface{}
+}
+
+// resourceSchema is a resource or data source as built by the provider.
+type resourceSchema struct {
+	schema tfsdk.Schema
+	header ResourceHeader
+}
+
+func (a *providerAdapter) SDKType() string {
+	return model.SDKTypeFramework
+}
+
+func (a *providerAdapter) ExportProvider(t *model.Trace) SchemaInfo {
+	return a.m.exportAttributes(a.provider.Attributes, t)
+}
+
+func (a *providerAdapter) ExportProviderMeta(t *model.Trace) SchemaInfo {
+	if a.providerMeta == nil {
+		return nil
+	}
+	return a.m.exportAttributes(a.providerMeta.Attributes, t)
+}
+
+func (a *providerAdapter) ProviderHeader() ProviderHeader {
+	// Configure is part of the Provider interface
+	return ProviderHeader{Configurable: true}
+}
+
+func (a *providerAdapter) Resources() map[string]interface{} {
+	return a.resources
+}
+
+func (a *providerAdapter) DataSources() map[string]interface{} {
+	return a.dataSources
+}
+
+func (a *providerAdapter) ExportResource(r interface{}, t *model.Trace) SchemaInfo {
+	return a.m.exportAttributes(r.(*resourceSchema).schema.Attributes, t)
+}
+
+// ExportTimeouts returns nil: the framework has no timeouts of its own.
+func (a *providerAdapter) ExportTimeouts(r interface{}) SchemaInfo {
+	return nil
+}
+
+func (a *providerAdapter) ResourceHeader(r interface{}) ResourceHeader {
+	return r.(*resourceSchema).header
+}
+
+// exportAttributes exports attributes in order of their names, so that
+// t fails at the same attribute every time. Framework schemas are plain
+// values that cannot refer to themselves, so each is traced by a pointer
+// of its own.
+func (m *FrameworkExtractor) exportAttributes(attributes map[string]tfsdk.Attribute, t *model.Trace) SchemaInfo {
+	names := make([]string, 0, len(attributes))
+	for k := range attributes {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	result := make(SchemaInfo)
+	for _, k := range names {
+		a := attributes[k]
+		t.Attribute(k, &a)
+		result[k] = m.export(a, t)
+		t.EndAttribute()
+	}
+	return result
+}
+
+func (m *FrameworkExtractor) export(a tfsdk.Attribute, t *model.Trace) SchemaDefinition {
+	ctx := context.Background()
+	item := SchemaDefinition{}
+
+	item.Optional = a.Optional
+	item.Required = a.Required
+	item.Computed = a.Computed
+	item.Sensitive = a.Sensitive
+	item.Deprecated = a.DeprecationMessage
+	item.Description, item.DescriptionKind = description(a.Description, a.MarkdownDescription)
+
+	for _, pm := range a.PlanModifiers {
+		// RequiresReplaceIf only replaces depending on the values
+		if _, ok := pm.(tfsdk.RequiresReplaceModifier); ok {
+			item.ForceNew = true
+		}
+	}
+	if len(a.Validators) > 0 {
+		item.Validation = &validators.Constraints{}
+		for _, v := range a.Validators {
+			var description string
+			t.Call(func() {
+				description = v.Description(ctx)
+			})
+			item.Validation.Descriptions = append(item.Validation.Descriptions, description)
+		}
+	}
+
+	if a.Attributes != nil {
+		m.exportNestedAttributes(&item, a.Attributes, t)
+	} else if a.Type != nil {
+		m.exportType(&item, a.Type, t)
+	}
+	return item
+}
+
+// exportNestedAttributes maps nested attributes onto a collection in
+// attribute mode whose elements hold the nested attributes.
+func (m *FrameworkExtractor) exportNestedAttributes(item *SchemaDefinition, nested tfsdk.NestedAttributes, t *model.Trace) {
+	item.ConfigImplicitMode = "Attr"
+	item.MinItems = int(nested.GetMinItems())
+	item.MaxItems = int(nested.GetMaxItems())
+	item.Elem = t.Block(&nested, func() SchemaInfo {
+		return m.exportAttributes(nested.GetAttributes(), t)
+	})
+	switch nested.GetNestingMode() {
+	case tfsdk.NestingModeSingle:
+		item.Type = "List"
+		item.MaxItems = 1
+		item.Nesting = model.NestingSingle
+	case tfsdk.NestingModeList:
+		item.Type = "List"
+	case tfsdk.NestingModeSet:
+		item.Type = "Set"
+	case tfsdk.NestingModeMap:
+		item.Type = "Map"
+		item.Nesting = model.NestingMap
+	}
+}
+
+// exportType sets the type and element type of item. Custom types are
+// exported through the Terraform type they are based on.
+func (m *FrameworkExtractor) exportType(item *SchemaDefinition, t attr.Type, trace *model.Trace) {
+	switch t := t.(type) {
+	case types.ListType:
+		item.Type = "List"
+		item.ConfigImplicitMode = "Attr"
+		item.Elem = m.exportElem(t.ElemType, trace)
+	case types.SetType:
+		item.Type = "Set"
+		item.ConfigImplicitMode = "Attr"
+		item.Elem = m.exportElem(t.ElemType, trace)
+	case types.MapType:
+		item.Type = "Map"
+		item.ConfigImplicitMode = "Attr"
+		item.Elem = m.exportElem(t.ElemType, trace)
+	case types.ObjectType:
+		item.Type = "Map"
+		item.ConfigImplicitMode = "Attr"
+		item.Elem = trace.Block(&t, func() SchemaInfo {
+			names := make([]string, 0, len(t.AttrTypes))
+			for k := range t.AttrTypes {
+				names = append(names, k)
+			}
+			sort.Strings(names)
+			info := make(SchemaInfo)
+			for _, k := range names {
+				at := t.AttrTypes[k]
+				trace.Attribute(k, &at)
+				attribute := SchemaDefinition{Required: true}
+				m.exportType(&attribute, at, trace)
+				trace.EndAttribute()
+				info[k] = attribute
+			}
+			return info
+		})
+	default:
+		if primitive := primitiveType(t); primitive != "" {
+			item.Type = primitive
+			return
+		}
+		item.CustomType = fmt.Sprintf("%T", t)
+		var tt tftypes.Type
+		trace.Call(func() {
+			tt = t.TerraformType(context.Background())
+		})
+		exportTerraformType(item, tt)
+	}
+}
+
+func (m *FrameworkExtractor) exportElem(t attr.Type, trace *model.Trace) *SchemaElement {
+	return trace.Elements(&t, func() SchemaDefinition {
+		elements := SchemaDefinition{}
+		m.exportType(&elements, t, trace)
+		return elements
+	})
+}
+
+// primitiveType returns the model type of the framework's own primitive
+// types, or "" for other types.
+func primitiveType(t attr.Type) string {
+	switch t {
+	case types.StringType:
+		return "String"
+	case types.BoolType:
+		return "Bool"
+	case types.Int64Type:
+		return "Int"
+	case types.Float64Type, types.NumberType:
+		return "Float"
+	}
+	return ""
+}
+
+// exportTerraformType sets the type and element type of item from the
+// Terraform type of a custom type.
+func exportTerraformType(item *SchemaDefinition, t tftypes.Type) {
+	elem := func(et tftypes.Type) *SchemaElement {
+		elements := SchemaDefinition{}
+		exportTerraformType(&elements, et)
+		return &SchemaElement{Type: "SchemaElements", ElementsType: elements.Type, Elements: &elements}
+	}
+	switch {
+	case t.Is(tftypes.String):
+		item.Type = "String"
+	case t.Is(tftypes.Bool):
+		item.Type = "Bool"
+	case t.Is(tftypes.Number):
+		item.Type = "Float"
+	case t.Is(tftypes.List{}):
+		item.Type = "List"
+		item.ConfigImplicitMode = "Attr"
+		item.Elem = elem(t.(tftypes.List).ElementType)
+	case t.Is(tftypes.Set{}):
+		item.Type = "Set"
+		item.ConfigImplicitMode = "Attr"
+		item.Elem = elem(t.(tftypes.Set).ElementType)
+	case t.Is(tftypes.Map{}):
+		item.Type = "Map"
+		item.ConfigImplicitMode = "Attr"
+		item.Elem = elem(t.(tftypes.Map).ElementType)
+	case t.Is(tftypes.Object{}):
+		item.Type = "Map"
+		item.ConfigImplicitMode = "Attr"
+		info := make(SchemaInfo)
+		for k, at := range t.(tftypes.Object).AttributeTypes {
+			attribute := SchemaDefinition{Required: true}
+			exportTerraformType(&attribute, at)
+			info[k] = attribute
+		}
+		item.Elem = &SchemaElement{Type: "SchemaInfo", Info: info}
+	default:
+		item.Type = "String"
+	}
+}
+
+// description prefers the plain text description, which is what the
+// SDK extractors export too.
+func description(plain, markdown string) (string, string) {
+	if plain != "" {
+		return plain, "plain"
+	}
+	if markdown != "" {
+		return markdown, "markdown"
+	}
+	return "", ""
+}
+
+// diagsError turns error diagnostics into an error.
+func diagsError(subject string, diags diag.Diagnostics) error {
+	if !diags.HasError() {
+		return nil
+	}
+	var messages []string
+	for _, d := range diags {
+		if d.Severity() == diag.SeverityError {
+			messages = append(messages, d.Summary()+": "+d.Detail())
+		}
+	}
+	return fmt.Errorf("%s: %s", subject, strings.Join(messages, "; "))
+}
+
+func (m *FrameworkExtractor) Generate(provider tfsdk.Provider, pi *ProviderInfo, outputPath string) {
+	outputFilePath := filepath.Join(outputPath, fmt.Sprintf("%s.json", pi.Name))
+
+	if err := m.DoGenerate(provider, pi, outputFilePath); err != nil {
+		fmt.Fprintln(os.Stderr, "Error: ", err.Error())
+		os.Exit(255)
+	}
+}
+
+func (m *FrameworkExtractor) DoGenerate(provider tfsdk.Provider, pi *ProviderInfo, outputFilePath string) error {
+	return model.EncodeFile(outputFilePath, func(w io.Writer) error {
+		return m.Encode(provider, pi, w)
+	})
+}
+
+// func main() {
+// 	var provider tfsdk.Provider
+// 	// provider = __NAME__.New()
+
+// 	env := command.DefaultEnv()
+// 	env.Export = func(pi *ProviderInfo, opts command.ExportOptions) (*ResourceProviderSchema, error) {
+// 		return (&FrameworkExtractor{MaxDepth: opts.MaxDepth}).Export(provider, pi)
+// 	}
+// 	env.Encode = func(pi *ProviderInfo, opts command.ExportOptions, w io.Writer) error {
+// 		return (&FrameworkExtractor{MaxDepth: opts.MaxDepth}).Encode(provider, pi, w)
+// 	}
+// 	os.Exit(command.Run(env, os.Args[1:]))
+// }
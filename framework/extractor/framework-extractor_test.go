@@ -0,0 +1,243 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package extractor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/evan-cleary/tf-schema-extractor/model"
+	"github.com/evan-cleary/tf-schema-extractor/validators"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// stubProvider is a framework provider that only has schemas.
+type stubProvider struct {
+	resources   map[string]tfsdk.ResourceType
+	dataSources map[string]tfsdk.DataSourceType
+	diags       diag.Diagnostics
+}
+
+func (p *stubProvider) GetSchema(context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{Attributes: map[string]tfsdk.Attribute{
+		"region": {Type: types.StringType, Required: true},
+	}}, nil
+}
+
+func (p *stubProvider) GetMetaSchema(context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{Attributes: map[string]tfsdk.Attribute{
+		"module": {Type: types.StringType, Optional: true},
+	}}, nil
+}
+
+func (p *stubProvider) Configure(context.Context, tfsdk.ConfigureProviderRequest, *tfsdk.ConfigureProviderResponse) {
+}
+
+func (p *stubProvider) GetResources(context.Context) (map[string]tfsdk.ResourceType, diag.Diagnostics) {
+	return p.resources, p.diags
+}
+
+func (p *stubProvider) GetDataSources(context.Context) (map[string]tfsdk.DataSourceType, diag.Diagnostics) {
+	return p.dataSources, nil
+}
+
+// stubType is a resource or data source type with schema s.
+type stubType tfsdk.Schema
+
+func (s stubType) GetSchema(context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema(s), nil
+}
+
+func (s stubType) NewResource(context.Context, tfsdk.Provider) (tfsdk.Resource, diag.Diagnostics) {
+	return nil, nil
+}
+
+func (s stubType) NewDataSource(context.Context, tfsdk.Provider) (tfsdk.DataSource, diag.Diagnostics) {
+	return nil, nil
+}
+
+// stubValidator describes itself as description.
+type stubValidator string
+
+func (v stubValidator) Description(context.Context) string {
+	return string(v)
+}
+
+func (v stubValidator) MarkdownDescription(context.Context) string {
+	return string(v)
+}
+
+func (v stubValidator) Validate(context.Context, tfsdk.ValidateAttributeRequest, *tfsdk.ValidateAttributeResponse) {
+}
+
+// customString is a custom type based on strings.
+type customString struct {
+	attr.Type
+}
+
+func stubThing() stubType {
+	nested := map[string]tfsdk.Attribute{
+		"port": {Type: types.Int64Type, Required: true},
+	}
+	return stubType{
+		Description: "A thing",
+		Version:     2,
+		Attributes: map[string]tfsdk.Attribute{
+			"id": {Type: types.StringType, Computed: true},
+			"name": {
+				Type:          types.StringType,
+				Required:      true,
+				PlanModifiers: tfsdk.AttributePlanModifiers{tfsdk.RequiresReplace()},
+				Validators:    []tfsdk.AttributeValidator{stubValidator("string length at least 1")},
+			},
+			"size": {
+				Type:     types.Float64Type,
+				Optional: true,
+				PlanModifiers: tfsdk.AttributePlanModifiers{tfsdk.RequiresReplaceIf(func(context.Context, attr.Value, attr.Value, *tftypes.AttributePath) (bool, diag.Diagnostics) {
+					return true, nil
+				}, "", "")},
+			},
+			"tags":      {Type: types.MapType{ElemType: types.StringType}, Optional: true},
+			"ports":     {Type: types.ListType{ElemType: types.SetType{ElemType: types.Int64Type}}, Optional: true},
+			"origin":    {Type: types.ObjectType{AttrTypes: map[string]attr.Type{"host": types.StringType}}, Optional: true},
+			"label":     {Type: customString{types.StringType}, Optional: true, MarkdownDescription: "A `label`"},
+			"endpoint":  {Attributes: tfsdk.SingleNestedAttributes(nested), Optional: true},
+			"rules":     {Attributes: tfsdk.ListNestedAttributes(nested, tfsdk.ListNestedAttributesOptions{MinItems: 1, MaxItems: 3}), Required: true},
+			"members":   {Attributes: tfsdk.SetNestedAttributes(nested, tfsdk.SetNestedAttributesOptions{}), Optional: true},
+			"listeners": {Attributes: tfsdk.MapNestedAttributes(nested, tfsdk.MapNestedAttributesOptions{}), Optional: true},
+		},
+	}
+}
+
+func stubFrameworkProvider() *stubProvider {
+	return &stubProvider{
+		resources: map[string]tfsdk.ResourceType{"stub_thing": stubThing()},
+		dataSources: map[string]tfsdk.DataSourceType{"stub_info": stubType{
+			DeprecationMessage: "Use stub_thing",
+			Attributes: map[string]tfsdk.Attribute{
+				"id": {Type: types.StringType, Required: true},
+			},
+		}},
+	}
+}
+
+func TestExport(t *testing.T) {
+	s, err := (&FrameworkExtractor{}).Export(stubFrameworkProvider(), &ProviderInfo{Name: "stub"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.SDKType != model.SDKTypeFramework {
+		t.Errorf("SDKType = %q", s.SDKType)
+	}
+	if want := (SchemaInfo{"region": {Type: "String", Required: true}}); !reflect.DeepEqual(s.Provider, want) {
+		t.Errorf("provider = %+v, want %+v", s.Provider, want)
+	}
+	if !s.ProviderHeader.Configurable || s.ProviderHeader.ProviderMeta == nil {
+		t.Errorf("provider header = %+v", s.ProviderHeader)
+	}
+
+	header := s.ResourceHeaders["stub_thing"]
+	if header.Description != "A thing" || header.SchemaVersion != 2 || !reflect.DeepEqual(header.Operations, []string{"create", "read", "update", "delete"}) {
+		t.Errorf("resource header = %+v", header)
+	}
+	if header := s.DataSourceHeaders["stub_info"]; header.DeprecationMessage != "Use stub_thing" || !reflect.DeepEqual(header.Operations, []string{"read"}) {
+		t.Errorf("data source header = %+v", header)
+	}
+
+	port := SchemaInfo{"port": {Type: "Int", Required: true}}
+	elements := func(d SchemaDefinition) *SchemaElement {
+		return &SchemaElement{Type: "SchemaElements", ElementsType: d.Type, Elements: &d}
+	}
+	tests := []struct {
+		name string
+		want SchemaDefinition
+	}{
+		{"id", SchemaDefinition{Type: "String", Computed: true}},
+		{"name", SchemaDefinition{Type: "String", Required: true, ForceNew: true,
+			Validation: &validators.Constraints{Descriptions: []string{"string length at least 1"}}}},
+		{"size", SchemaDefinition{Type: "Float", Optional: true}},
+		{"tags", SchemaDefinition{Type: "Map", Optional: true, ConfigImplicitMode: "Attr",
+			Elem: elements(SchemaDefinition{Type: "String"})}},
+		{"ports", SchemaDefinition{Type: "List", Optional: true, ConfigImplicitMode: "Attr",
+			Elem: elements(SchemaDefinition{Type: "Set", ConfigImplicitMode: "Attr", Elem: elements(SchemaDefinition{Type: "Int"})})}},
+		{"origin", SchemaDefinition{Type: "Map", Optional: true, ConfigImplicitMode: "Attr",
+			Elem: &SchemaElement{Type: "SchemaInfo", Info: SchemaInfo{"host": {Type: "String", Required: true}}}}},
+		{"label", SchemaDefinition{Type: "String", Optional: true, CustomType: "extractor.customString",
+			Description: "A `label`", DescriptionKind: "markdown"}},
+		{"endpoint", SchemaDefinition{Type: "List", Optional: true, MaxItems: 1, Nesting: model.NestingSingle,
+			ConfigImplicitMode: "Attr", Elem: &SchemaElement{Type: "SchemaInfo", Info: port}}},
+		{"rules", SchemaDefinition{Type: "List", Required: true, MinItems: 1, MaxItems: 3,
+			ConfigImplicitMode: "Attr", Elem: &SchemaElement{Type: "SchemaInfo", Info: port}}},
+		{"members", SchemaDefinition{Type: "Set", Optional: true,
+			ConfigImplicitMode: "Attr", Elem: &SchemaElement{Type: "SchemaInfo", Info: port}}},
+		{"listeners", SchemaDefinition{Type: "Map", Optional: true, Nesting: model.NestingMap,
+			ConfigImplicitMode: "Attr", Elem: &SchemaElement{Type: "SchemaInfo", Info: port}}},
+	}
+	thing := s.Resources["stub_thing"]
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := thing[test.name]; !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got\n%+v\nwant\n%+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestEncodeWorkers(t *testing.T) {
+	p := stubFrameworkProvider()
+	for _, name := range []string{"stub_a", "stub_b", "stub_c", "stub_d", "stub_e"} {
+		p.resources[name] = stubThing()
+	}
+	pi := &ProviderInfo{Name: "stub"}
+	var serial, concurrent bytes.Buffer
+	if err := (&FrameworkExtractor{}).Encode(p, pi, &serial); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&FrameworkExtractor{Workers: 4}).Encode(p, pi, &concurrent); err != nil {
+		t.Fatal(err)
+	}
+	if serial.String() != concurrent.String() {
+		t.Errorf("Encode() with 4 workers =\n%s\nwant\n%s", concurrent.String(), serial.String())
+	}
+}
+
+func TestExportMaxDepth(t *testing.T) {
+	p := stubFrameworkProvider()
+	_, err := (&FrameworkExtractor{MaxDepth: 1}).Export(p, &ProviderInfo{Name: "stub"})
+	var depthErr *model.DepthError
+	if !errors.As(err, &depthErr) {
+		t.Fatalf("Export() error = %v, want a DepthError", err)
+	}
+	if want := "stub_thing.ports.*"; depthErr.Path != want {
+		t.Errorf("DepthError.Path = %q, want %q", depthErr.Path, want)
+	}
+}
+
+func TestExportErrors(t *testing.T) {
+	p := stubFrameworkProvider()
+	p.diags.AddError("Invalid provider", "no resources")
+	_, err := (&FrameworkExtractor{}).Export(p, &ProviderInfo{Name: "stub"})
+	if want := "provider: Invalid provider: no resources"; err == nil || err.Error() != want {
+		t.Errorf("Export() error = %v, want %s", err, want)
+	}
+}
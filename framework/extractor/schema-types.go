@@ -0,0 +1,41 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package extractor
+
+import (
+	"io"
+
+	"github.com/evan-cleary/tf-schema-extractor/model"
+)
+
+// The schema model is shared by every SDK adapter; these aliases keep it
+// available from this package.
+type (
+	SchemaElement          = model.SchemaElement
+	SchemaDefinition       = model.SchemaDefinition
+	SchemaDefault          = model.SchemaDefault
+	SchemaInfo             = model.SchemaInfo
+	SchemaInfoWithTimeouts = model.SchemaInfoWithTimeouts
+	ResourceProviderSchema = model.ResourceProviderSchema
+	ProviderHeader         = model.ProviderHeader
+	ResourceHeader         = model.ResourceHeader
+	ProviderInfo           = model.ProviderInfo
+)
+
+// Load reads a schema in the format written by DoGenerate.
+func Load(r io.Reader) (*ResourceProviderSchema, error) {
+	return model.Load(r)
+}
@@ -4,9 +4,18 @@ go 1.15
 
 require (
 	github.com/gregjones/httpcache v0.0.0-20190611155906-901d90724c79 // indirect
+	github.com/hashicorp/go-cty v1.4.1-0.20200414143053-d3edf31b6320
+	github.com/hashicorp/go-hclog v0.15.0
+	github.com/hashicorp/go-plugin v1.4.1
+	github.com/hashicorp/hcl/v2 v2.8.2
 	github.com/hashicorp/terraform v0.14.7
+	github.com/hashicorp/terraform-plugin-framework v0.4.2
+	github.com/hashicorp/terraform-plugin-go v0.4.0
 	github.com/hashicorp/terraform-plugin-sdk v1.16.0
-	github.com/hashicorp/terraform-plugin-sdk/v2 v2.4.4
+	github.com/hashicorp/terraform-plugin-sdk/v2 v2.8.0
 	github.com/peterbourgon/diskv v2.0.1+incompatible // indirect
+	github.com/zclconf/go-cty v1.8.4
+	google.golang.org/grpc v1.32.0
+	google.golang.org/protobuf v1.25.0
 	k8s.io/client-go v11.0.0+incompatible // indirect
 )
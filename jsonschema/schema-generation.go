@@ -0,0 +1,321 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package jsonschema
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/evan-cleary/tf-schema-extractor/model"
+)
+
+// Generate returns a JSON Schema document for .tf.json files that use
+// the provider s. The provider block, each resource and each data source
+// are defined under $defs as "provider", "resource.<name>" and
+// "data.<name>", so they can also be referenced on their own.
+//
+// Every attribute also accepts a string, since strings are template
+// expressions in Terraform's JSON syntax; their values are not checked.
+// Nested blocks may be given as an object or as an array of objects.
+// Computed attributes that cannot be configured are rejected.
+func Generate(s *model.ResourceProviderSchema) *Schema {
+	result := &Schema{
+		Dialect: Dialect,
+		Title:   "Terraform JSON configuration for provider " + s.Name,
+		Type:    "object",
+		Defs:    make(map[string]*Schema),
+	}
+	g := &generator{s: s, defs: result.Defs}
+
+	provider := g.block(s.Provider, "")
+	provider.Properties["alias"] = &Schema{Type: "string"}
+	provider.Properties["version"] = &Schema{Type: "string"}
+	result.Defs["provider"] = provider
+
+	resources := make(map[string]*Schema)
+	for name, info := range s.Resources {
+		def := "resource." + name
+		result.Defs[def] = header(g.block(model.SchemaInfo(info), ""), s.ResourceHeaders[name], resourceMetaArguments)
+		resources[name] = &Schema{Type: "object", AdditionalProperties: ref(def)}
+	}
+	dataSources := make(map[string]*Schema)
+	for name, info := range s.DataSources {
+		def := "data." + name
+		result.Defs[def] = header(g.block(model.SchemaInfo(info), ""), s.DataSourceHeaders[name], dataMetaArguments)
+		dataSources[name] = &Schema{Type: "object", AdditionalProperties: ref(def)}
+	}
+
+	// Other providers' blocks may appear in the same file
+	result.Properties = map[string]*Schema{
+		"provider": {
+			Type:       "object",
+			Properties: map[string]*Schema{s.Name: oneOrMany(ref("provider"), 0, 0)},
+		},
+		"resource": {Type: "object", Properties: resources},
+		"data":     {Type: "object", Properties: dataSources},
+	}
+	return result
+}
+
+// Write writes the document generated for s.
+func Write(s *model.ResourceProviderSchema, w io.Writer) error {
+	data, err := json.MarshalIndent(Generate(s), "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	_, err = w.Write(data)
+	return err
+}
+
+// Meta-arguments Terraform accepts in every resource or data block.
+var (
+	dataMetaArguments = map[string]*Schema{
+		"count":      {Type: []string{"number", "string"}},
+		"for_each":   {Type: []string{"object", "array", "string"}},
+		"provider":   {Type: "string"},
+		"depends_on": {Type: "array", Items: &Schema{Type: "string"}},
+		"lifecycle":  {Type: "object"},
+	}
+	resourceMetaArguments = map[string]*Schema{
+		"count":       dataMetaArguments["count"],
+		"for_each":    dataMetaArguments["for_each"],
+		"provider":    dataMetaArguments["provider"],
+		"depends_on":  dataMetaArguments["depends_on"],
+		"lifecycle":   dataMetaArguments["lifecycle"],
+		"connection":  {Type: "object"},
+		"provisioner": {},
+	}
+)
+
+// generator generates the schemas of the blocks and attributes of s.
+type generator struct {
+	s    *model.ResourceProviderSchema
+	defs map[string]*Schema
+}
+
+// recursive returns a reference to the schema of a nested block, or of a
+// value if block is not set, that refers back to the schema at path
+// further up, see model.ResolveBackRef. Each of them is defined once
+// under $defs as "recursive.<path>" or "recursive-block.<path>", in
+// terms of itself. Unresolvable paths accept any value.
+func (g *generator) recursive(path string, block bool) *Schema {
+	def := "recursive." + path
+	if block {
+		def = "recursive-block." + path
+	}
+	if _, ok := g.defs[def]; ok {
+		return ref(def)
+	}
+	e, err := model.ResolveBackRef(g.s, path)
+	if err != nil {
+		return &Schema{}
+	}
+	// Placeholder for the references from within
+	g.defs[def] = &Schema{}
+	if block {
+		g.defs[def] = g.block(e.Info, "")
+	} else {
+		g.defs[def] = g.elem(e)
+	}
+	return ref(def)
+}
+
+func ref(def string) *Schema {
+	return &Schema{Ref: "#/$defs/" + def}
+}
+
+// header adds the resource-level description, deprecation and
+// meta-arguments to the schema of a resource or data source.
+func header(s *Schema, h model.ResourceHeader, metaArguments map[string]*Schema) *Schema {
+	s.Description = h.Description
+	s.Deprecated = h.DeprecationMessage != ""
+	for name, argument := range metaArguments {
+		if _, ok := s.Properties[name]; !ok {
+			s.Properties[name] = argument
+		}
+	}
+	return s
+}
+
+// block returns the schema of a block holding info. prefix is the path
+// of the block within its resource, such as "rule.0.", against which
+// ConflictsWith paths are resolved.
+func (g *generator) block(info model.SchemaInfo, prefix string) *Schema {
+	result := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			// Comments are allowed in any JSON block
+			"//": {},
+		},
+		AdditionalProperties: false,
+	}
+	hasBlocks := false
+	for _, name := range sortedNames(info) {
+		d := info[name]
+		if d.Computed && !d.Optional {
+			continue
+		}
+		var property *Schema
+		if g.isBlock(d) {
+			hasBlocks = true
+			property = g.nestedBlock(d, prefix+name)
+		} else {
+			property = g.attribute(d)
+		}
+		property.Description = d.Description
+		property.Deprecated = d.Deprecated != ""
+		result.Properties[name] = property
+		if d.Required {
+			result.Required = append(result.Required, name)
+		}
+
+		if conflicts := siblings(d.ConflictsWith, prefix); len(conflicts) > 0 {
+			if result.DependentSchemas == nil {
+				result.DependentSchemas = make(map[string]*Schema)
+			}
+			result.DependentSchemas[name] = &Schema{Not: &Schema{AnyOf: requiredEach(conflicts)}}
+		}
+	}
+	if hasBlocks {
+		result.Properties["dynamic"] = &Schema{Type: "object"}
+	}
+	return result
+}
+
+// isBlock reports whether d is a nested block, including one that
+// refers back to a block further up.
+func (g *generator) isBlock(d model.SchemaDefinition) bool {
+	if d.IsBlock && d.Elem != nil && d.Elem.Type == model.ElementTypeBackRef {
+		e, err := model.ResolveBackRef(g.s, d.Elem.Ref)
+		return err == nil && e.Type == "SchemaInfo"
+	}
+	return d.IsBlock && d.Elem != nil && d.Elem.Type == "SchemaInfo"
+}
+
+// nestedBlock allows a block to be written as a single object, unless
+// it must appear more than once, or as an array of objects.
+func (g *generator) nestedBlock(d model.SchemaDefinition, path string) *Schema {
+	var body *Schema
+	if d.Elem.Type == model.ElementTypeBackRef {
+		body = g.recursive(d.Elem.Ref, true)
+	} else {
+		body = g.block(d.Elem.Info, path+".0.")
+	}
+	return oneOrMany(body, d.MinItems, d.MaxItems)
+}
+
+func oneOrMany(item *Schema, minItems, maxItems int) *Schema {
+	many := &Schema{Type: "array", Items: item}
+	if minItems > 0 {
+		many.MinItems = &minItems
+	}
+	if maxItems > 0 {
+		many.MaxItems = &maxItems
+	}
+	if minItems > 1 {
+		return many
+	}
+	return &Schema{AnyOf: []*Schema{item, many}}
+}
+
+// attribute returns the schema of an attribute's value. Strings are
+// accepted for every type since they may hold an expression.
+func (g *generator) attribute(d model.SchemaDefinition) *Schema {
+	switch d.Type {
+	case "String":
+		return &Schema{Type: "string"}
+	case "Bool":
+		return &Schema{Type: []string{"boolean", "string"}}
+	case "Int", "Float":
+		return &Schema{Type: []string{"number", "string"}}
+	case "List", "Set":
+		if d.Nesting == model.NestingSingle && d.Elem != nil && d.Elem.Type == "SchemaInfo" {
+			return g.object(d.Elem.Info)
+		}
+		result := &Schema{Type: []string{"array", "string"}, Items: g.elem(d.Elem)}
+		if d.MinItems > 0 {
+			result.MinItems = &d.MinItems
+		}
+		if d.MaxItems > 0 {
+			result.MaxItems = &d.MaxItems
+		}
+		return result
+	case "Map":
+		if d.Nesting != model.NestingMap && d.Elem != nil && d.Elem.Type == "SchemaInfo" {
+			return g.object(d.Elem.Info)
+		}
+		return &Schema{Type: []string{"object", "string"}, AdditionalProperties: g.elem(d.Elem)}
+	}
+	return &Schema{}
+}
+
+func (g *generator) elem(e *model.SchemaElement) *Schema {
+	switch {
+	case e == nil:
+		return &Schema{Type: "string"}
+	case e.Elements != nil:
+		return g.attribute(*e.Elements)
+	case e.Type == "SchemaInfo":
+		return g.object(e.Info)
+	case e.Type == model.ElementTypeBackRef:
+		return g.recursive(e.Ref, false)
+	case e.Value != "":
+		return g.attribute(model.SchemaDefinition{Type: e.Value})
+	}
+	return &Schema{}
+}
+
+// object returns the schema of an object value, whose attributes are
+// all optional to allow for null.
+func (g *generator) object(info model.SchemaInfo) *Schema {
+	result := &Schema{Type: []string{"object", "string"}, Properties: make(map[string]*Schema)}
+	for name, d := range info {
+		result.Properties[name] = g.attribute(d)
+	}
+	return result
+}
+
+// siblings returns the ConflictsWith paths that name attributes of the
+// block at prefix, relative to it. Other paths cannot be expressed.
+func siblings(paths []string, prefix string) []string {
+	var result []string
+	for _, path := range paths {
+		if strings.HasPrefix(path, prefix) && !strings.Contains(path[len(prefix):], ".") {
+			result = append(result, path[len(prefix):])
+		}
+	}
+	return result
+}
+
+func requiredEach(names []string) []*Schema {
+	result := make([]*Schema, 0, len(names))
+	for _, name := range names {
+		result = append(result, &Schema{Required: []string{name}})
+	}
+	return result
+}
+
+func sortedNames(info model.SchemaInfo) []string {
+	names := make([]string, 0, len(info))
+	for name := range info {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,183 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/evan-cleary/tf-schema-extractor/model"
+)
+
+func testSchema() *model.ResourceProviderSchema {
+	s := model.NewResourceProviderSchema(&model.ProviderInfo{Name: "test"}, model.SDKTypeSDK2)
+	s.Provider["region"] = model.SchemaDefinition{Type: "String", Required: true}
+	s.Resources["test_thing"] = model.SchemaInfoWithTimeouts{
+		"id":    {Type: "String", Computed: true},
+		"name":  {Type: "String", Required: true, Description: "The name"},
+		"size":  {Type: "Int", Optional: true, ConflictsWith: []string{"tier", "other.0.x"}},
+		"tier":  {Type: "String", Optional: true, Deprecated: "Use size"},
+		"tags":  {Type: "Map", Optional: true, Elem: &model.SchemaElement{Type: "SchemaElements", ElementsType: "String", Elements: &model.SchemaDefinition{Type: "String"}}},
+		"ports": {Type: "List", Optional: true, MaxItems: 2, Elem: &model.SchemaElement{Type: "SchemaElements", ElementsType: "Int", Elements: &model.SchemaDefinition{Type: "Int"}}},
+		"endpoint": {Type: "List", Optional: true, MaxItems: 1, Nesting: model.NestingSingle, Elem: &model.SchemaElement{Type: "SchemaInfo", Info: model.SchemaInfo{
+			"url": {Type: "String", Required: true},
+		}}},
+		"origin": {Type: "Map", Optional: true, Elem: &model.SchemaElement{Type: "SchemaInfo", Info: model.SchemaInfo{
+			"host": {Type: "String", Required: true},
+		}}},
+		"listeners": {Type: "Map", Optional: true, Nesting: model.NestingMap, Elem: &model.SchemaElement{Type: "SchemaInfo", Info: model.SchemaInfo{
+			"port": {Type: "Int", Required: true},
+		}}},
+		"rule": {Type: "List", Required: true, IsBlock: true, MinItems: 1, MaxItems: 3, Elem: &model.SchemaElement{Type: "SchemaInfo", Info: model.SchemaInfo{
+			"from": {Type: "Int", Optional: true, ConflictsWith: []string{"rule.0.to"}},
+			"to":   {Type: "Int", Optional: true},
+		}}},
+	}
+	s.ResourceHeaders["test_thing"] = model.ResourceHeader{Description: "A thing", DeprecationMessage: "Use test_other"}
+	s.Resources["test_tree"] = model.SchemaInfoWithTimeouts{
+		"name":  {Type: "String", Optional: true},
+		"child": {Type: "List", Optional: true, IsBlock: true, Elem: &model.SchemaElement{Type: model.ElementTypeBackRef, Ref: "test_tree"}},
+	}
+	s.DataSources["test_info"] = model.SchemaInfoWithTimeouts{
+		"filter": {Type: "String", Optional: true},
+		"id":     {Type: "String", Computed: true},
+	}
+	return s
+}
+
+// assertJSON compares the JSON encoding of got with want.
+func assertJSON(t *testing.T, what string, got interface{}, want string) {
+	t.Helper()
+	data, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var g, w interface{}
+	if err = json.Unmarshal(data, &g); err != nil {
+		t.Fatal(err)
+	}
+	if err = json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("%s: invalid expected JSON: %s", what, err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("%s =\n%s\nwant\n%s", what, data, want)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	doc := Generate(testSchema())
+	if doc.Dialect != Dialect {
+		t.Errorf("$schema = %q, want %q", doc.Dialect, Dialect)
+	}
+	assertJSON(t, "provider", doc.Properties["provider"], `{"type": "object", "properties": {"test": {"anyOf": [
+		{"$ref": "#/$defs/provider"},
+		{"type": "array", "items": {"$ref": "#/$defs/provider"}}
+	]}}}`)
+	assertJSON(t, "resource", doc.Properties["resource"], `{"type": "object", "properties": {
+		"test_thing": {"type": "object", "additionalProperties": {"$ref": "#/$defs/resource.test_thing"}},
+		"test_tree": {"type": "object", "additionalProperties": {"$ref": "#/$defs/resource.test_tree"}}
+	}}`)
+	assertJSON(t, "data", doc.Properties["data"], `{"type": "object", "properties": {
+		"test_info": {"type": "object", "additionalProperties": {"$ref": "#/$defs/data.test_info"}}
+	}}`)
+	assertJSON(t, "$defs.provider", doc.Defs["provider"], `{
+		"type": "object",
+		"properties": {
+			"//": {},
+			"region": {"type": "string"},
+			"alias": {"type": "string"},
+			"version": {"type": "string"}
+		},
+		"additionalProperties": false,
+		"required": ["region"]
+	}`)
+}
+
+func TestGenerateResource(t *testing.T) {
+	thing := Generate(testSchema()).Defs["resource.test_thing"]
+	if thing.Description != "A thing" || !thing.Deprecated {
+		t.Errorf("header = %q, deprecated %t", thing.Description, thing.Deprecated)
+	}
+	if _, ok := thing.Properties["id"]; ok {
+		t.Errorf("computed id is configurable")
+	}
+	for _, argument := range []string{"count", "for_each", "provider", "depends_on", "lifecycle", "connection", "provisioner"} {
+		if _, ok := thing.Properties[argument]; !ok {
+			t.Errorf("meta-argument %s is missing", argument)
+		}
+	}
+	if got, want := thing.Required, []string{"name", "rule"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("required = %q, want %q", got, want)
+	}
+	assertJSON(t, "dependentSchemas", thing.DependentSchemas, `{"size": {"not": {"anyOf": [{"required": ["tier"]}]}}}`)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"name", `{"type": "string", "description": "The name"}`},
+		{"size", `{"type": ["number", "string"]}`},
+		{"tier", `{"type": "string", "deprecated": true}`},
+		{"tags", `{"type": ["object", "string"], "additionalProperties": {"type": "string"}}`},
+		{"ports", `{"type": ["array", "string"], "items": {"type": ["number", "string"]}, "maxItems": 2}`},
+		{"endpoint", `{"type": ["object", "string"], "properties": {"url": {"type": "string"}}}`},
+		{"origin", `{"type": ["object", "string"], "properties": {"host": {"type": "string"}}}`},
+		{"listeners", `{"type": ["object", "string"], "additionalProperties": {
+			"type": ["object", "string"], "properties": {"port": {"type": ["number", "string"]}}
+		}}`},
+		{"rule", `{"anyOf": [
+			{
+				"type": "object",
+				"properties": {"//": {}, "from": {"type": ["number", "string"]}, "to": {"type": ["number", "string"]}},
+				"additionalProperties": false,
+				"dependentSchemas": {"from": {"not": {"anyOf": [{"required": ["to"]}]}}}
+			},
+			{"type": "array", "items": {
+				"type": "object",
+				"properties": {"//": {}, "from": {"type": ["number", "string"]}, "to": {"type": ["number", "string"]}},
+				"additionalProperties": false,
+				"dependentSchemas": {"from": {"not": {"anyOf": [{"required": ["to"]}]}}}
+			}, "minItems": 1, "maxItems": 3}
+		]}`},
+		{"dynamic", `{"type": "object"}`},
+	}
+	for _, test := range tests {
+		assertJSON(t, test.name, thing.Properties[test.name], test.want)
+	}
+}
+
+func TestGenerateRecursive(t *testing.T) {
+	doc := Generate(testSchema())
+	block := `{
+		"type": "object",
+		"properties": {
+			"//": {},
+			"name": {"type": "string"},
+			"child": {"anyOf": [
+				{"$ref": "#/$defs/recursive-block.test_tree"},
+				{"type": "array", "items": {"$ref": "#/$defs/recursive-block.test_tree"}}
+			]},
+			"dynamic": {"type": "object"}
+		},
+		"additionalProperties": false
+	}`
+	assertJSON(t, "$defs.recursive-block.test_tree", doc.Defs["recursive-block.test_tree"], block)
+	assertJSON(t, "child", doc.Defs["resource.test_tree"].Properties["child"], `{"anyOf": [
+		{"$ref": "#/$defs/recursive-block.test_tree"},
+		{"type": "array", "items": {"$ref": "#/$defs/recursive-block.test_tree"}}
+	]}`)
+}
@@ -0,0 +1,246 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/evan-cleary/tf-schema-extractor/model"
+)
+
+// Values of Finding.Scope.
+const (
+	ScopeProvider   = "provider"
+	ScopeResource   = "resource"
+	ScopeDataSource = "data"
+)
+
+// Config selects the rules to run and their severities.
+type Config struct {
+	// Severities by rule name, overriding the rules' own. SeverityOff
+	// disables a rule.
+	Rules map[string]string `json:"rules"`
+}
+
+// LoadConfig reads a JSON Config such as
+// {"rules": {"missing-description": "off", "snake-case": "error"}}.
+func LoadConfig(r io.Reader) (*Config, error) {
+	result := new(Config)
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(result); err != nil {
+		return nil, err
+	}
+	return result, result.Validate()
+}
+
+// Validate checks that the Config names known rules and severities.
+func (c *Config) Validate() error {
+	for name, severity := range c.Rules {
+		if _, ok := Rules[name]; !ok {
+			return fmt.Errorf("unknown rule %q", name)
+		}
+		switch severity {
+		case SeverityError, SeverityWarning, SeverityInfo, SeverityOff:
+		default:
+			return fmt.Errorf("rule %s: unknown severity %q", name, severity)
+		}
+	}
+	return nil
+}
+
+// severity returns the severity rule runs with.
+func (c *Config) severity(rule *Rule) string {
+	if c != nil {
+		if severity, ok := c.Rules[rule.Name]; ok {
+			return severity
+		}
+	}
+	return rule.Severity
+}
+
+// Finding is a problem reported by a rule.
+type Finding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Scope    string `json:"scope"`
+	// Empty for the provider
+	Resource string `json:"resource,omitempty"`
+	// Empty for findings about the resource itself
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+// Location names what the finding is about, e.g.
+// "resource.aws_instance.ebs_block_device.volume_size".
+func (f Finding) Location() string {
+	parts := []string{f.Scope}
+	if f.Resource != "" {
+		parts = append(parts, f.Resource)
+	}
+	if f.Path != "" {
+		parts = append(parts, f.Path)
+	}
+	return strings.Join(parts, ".")
+}
+
+// suppression matches inline suppressions such as
+// "<!-- lint:ignore missing-description,snake-case -->", which are read
+// from the description of the attribute, block or resource the finding
+// is about or of any block or resource containing it. The HTML comment
+// keeps them out of rendered documentation.
+var suppression = regexp.MustCompile(`(?:<!--\s*)?lint:ignore\s+([a-z0-9,-]+)(?:\s*-->)?`)
+
+// suppressed returns the rules suppressed by a description.
+func suppressed(description string) map[string]bool {
+	var result map[string]bool
+	for _, m := range suppression.FindAllStringSubmatch(description, -1) {
+		if result == nil {
+			result = make(map[string]bool)
+		}
+		for _, name := range strings.Split(m[1], ",") {
+			result[name] = true
+		}
+	}
+	return result
+}
+
+func stripSuppressions(description string) string {
+	return suppression.ReplaceAllString(description, "")
+}
+
+// Lint runs the rules selected by config, which may be nil for the
+// rules' defaults, against s. Findings are ordered by location.
+func Lint(s *model.ResourceProviderSchema, config *Config) ([]Finding, error) {
+	if config != nil {
+		if err := config.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	l := &linter{config: config, schema: s}
+	for _, name := range sortedRules() {
+		if rule := Rules[name]; config.severity(rule) != SeverityOff {
+			l.rules = append(l.rules, rule)
+		}
+	}
+
+	l.attributes(ScopeProvider, "", "", s.Provider, s.Provider, nil)
+	for _, group := range []struct {
+		scope   string
+		schemas map[string]model.SchemaInfoWithTimeouts
+		headers map[string]model.ResourceHeader
+	}{
+		{ScopeResource, s.Resources, s.ResourceHeaders},
+		{ScopeDataSource, s.DataSources, s.DataSourceHeaders},
+	} {
+		names := make([]string, 0, len(group.schemas))
+		for name := range group.schemas {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			header, hasHeader := group.headers[name]
+			r := &Resource{Scope: group.scope, Name: name, ProviderName: s.Name, Header: header, HasHeader: hasHeader}
+			ignored := suppressed(header.Description)
+			for _, rule := range l.rules {
+				if rule.resource != nil && !ignored[rule.Name] {
+					l.report(rule, group.scope, name, "", rule.resource(r))
+				}
+			}
+
+			info := make(model.SchemaInfo)
+			for k, d := range group.schemas[name] {
+				// Added by the extractors, not by the provider's authors
+				if k != model.TimeoutsConfigKey {
+					info[k] = d
+				}
+			}
+			l.attributes(group.scope, name, "", info, info, ignored)
+		}
+	}
+	return l.findings, nil
+}
+
+type linter struct {
+	config   *Config
+	schema   *model.ResourceProviderSchema
+	rules    []*Rule
+	findings []Finding
+}
+
+func (l *linter) report(rule *Rule, scope, resource, path string, messages []string) {
+	for _, message := range messages {
+		l.findings = append(l.findings, Finding{
+			Rule:     rule.Name,
+			Severity: l.config.severity(rule),
+			Scope:    scope,
+			Resource: resource,
+			Path:     path,
+			Message:  message,
+		})
+	}
+}
+
+// attributes checks info and the blocks nested in it. ignored holds the
+// rules suppressed by the enclosing blocks and resource.
+func (l *linter) attributes(scope, resource, path string, info, root model.SchemaInfo, ignored map[string]bool) {
+	names := make([]string, 0, len(info))
+	for name := range info {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		d := info[name]
+		attributePath := name
+		if path != "" {
+			attributePath = path + "." + name
+		}
+		attributeIgnored := ignored
+		if own := suppressed(d.Description); own != nil {
+			attributeIgnored = make(map[string]bool)
+			for rule := range ignored {
+				attributeIgnored[rule] = true
+			}
+			for rule := range own {
+				attributeIgnored[rule] = true
+			}
+		}
+
+		a := &Attribute{Scope: scope, Resource: resource, Path: attributePath, Name: name, Definition: d, Root: root, Schema: l.schema}
+		for _, rule := range l.rules {
+			if rule.attribute != nil && !attributeIgnored[rule.Name] {
+				l.report(rule, scope, resource, attributePath, rule.attribute(a))
+			}
+		}
+		if d.Elem != nil && d.Elem.Info != nil {
+			l.attributes(scope, resource, attributePath, d.Elem.Info, root, attributeIgnored)
+		}
+	}
+}
+
+func sortedRules() []string {
+	names := make([]string, 0, len(Rules))
+	for name := range Rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
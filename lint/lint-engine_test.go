@@ -0,0 +1,138 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package lint
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/evan-cleary/tf-schema-extractor/model"
+)
+
+// lintFixture returns a schema with one problem per rule; everything
+// else is described.
+func lintFixture() *model.ResourceProviderSchema {
+	s := model.NewResourceProviderSchema(&model.ProviderInfo{Name: "test"}, model.SDKTypeSDK2)
+	s.Provider["region"] = model.SchemaDefinition{Type: "String", Optional: true}
+	s.Resources["test_thing"] = model.SchemaInfoWithTimeouts{
+		"id":   {Type: "String", Required: true, Computed: true, Description: "The ID"},
+		"name": {Type: "String", Required: true, Description: "The name", Default: &model.SchemaDefault{Source: model.DefaultSourceStatic, Value: "x"}},
+		"size": {Type: "Int", Optional: true, MaxItems: 1, Description: "The size", ConflictsWith: []string{"name", "rule.0.cidr", "rule.0.missing"}},
+		"rule": {Type: "List", Optional: true, IsBlock: true, Description: "The rules", Elem: &model.SchemaElement{Type: "SchemaInfo", Info: model.SchemaInfo{
+			"cidr":     {Type: "String", Optional: true, Description: "The CIDR"},
+			"portName": {Type: "String", Optional: true, Description: "The port"},
+		}}},
+		"tree":                  {Type: "List", Optional: true, IsBlock: true, Description: "Recursive", Elem: &model.SchemaElement{Type: model.ElementTypeBackRef, Ref: "test_thing"}},
+		"peer":                  {Type: "String", Optional: true, Description: "A peer", ExactlyOneOf: []string{"tree.0.rule.0.cidr"}},
+		model.TimeoutsConfigKey: model.SingleBlock(model.SchemaInfo{model.TimeoutCreate: {Type: "String", Optional: true}}),
+	}
+	s.ResourceHeaders["test_thing"] = model.ResourceHeader{Description: "A thing"}
+	s.Resources["other_Thing"] = model.SchemaInfoWithTimeouts{}
+	s.ResourceHeaders["other_Thing"] = model.ResourceHeader{Description: "Another thing"}
+	s.DataSources["test_info"] = model.SchemaInfoWithTimeouts{"id": {Type: "String", Computed: true, Description: "The ID"}}
+	return s
+}
+
+// render lists findings compactly for comparison.
+func render(findings []Finding) string {
+	var lines []string
+	for _, f := range findings {
+		lines = append(lines, fmt.Sprintf("%s %s %s: %s", f.Severity, f.Rule, f.Location(), f.Message))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestLint(t *testing.T) {
+	findings, err := Lint(lintFixture(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"warning missing-description provider.region: missing description",
+		"warning provider-prefix resource.other_Thing: name \"other_Thing\" does not start with \"test_\"",
+		"warning snake-case resource.other_Thing: name \"other_Thing\" is not snake_case",
+		"error required-computed resource.test_thing.id: both Required and Computed",
+		"error required-default resource.test_thing.name: Required with a default, which is never used",
+		"warning snake-case resource.test_thing.rule.portName: name \"portName\" is not snake_case",
+		"error items-non-collection resource.test_thing.size: MaxItems or MinItems set on type Int",
+		"error unknown-reference resource.test_thing.size: ConflictsWith refers to unknown attribute \"rule.0.missing\"",
+	}, "\n")
+	if got := render(findings); got != want {
+		t.Errorf("Lint() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLintConfig(t *testing.T) {
+	config, err := LoadConfig(strings.NewReader(`{"rules": {"missing-description": "off", "snake-case": "error", "provider-prefix": "info"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := model.NewResourceProviderSchema(&model.ProviderInfo{Name: "test"}, model.SDKTypeSDK2)
+	s.Resources["test_Thing"] = model.SchemaInfoWithTimeouts{"x": {Type: "String", Optional: true}}
+	s.DataSources["info"] = model.SchemaInfoWithTimeouts{}
+	findings, err := Lint(s, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"error snake-case resource.test_Thing: name \"test_Thing\" is not snake_case",
+		"info provider-prefix data.info: name \"info\" does not start with \"test_\"",
+	}, "\n")
+	if got := render(findings); got != want {
+		t.Errorf("Lint() =\n%s\nwant\n%s", got, want)
+	}
+
+	for _, doc := range []string{
+		`{"rules": {"no-such-rule": "error"}}`,
+		`{"rules": {"snake-case": "fatal"}}`,
+		`{"rule": {}}`,
+	} {
+		if _, err := LoadConfig(strings.NewReader(doc)); err == nil {
+			t.Errorf("LoadConfig(%s) succeeded", doc)
+		}
+	}
+	if _, err := Lint(s, &Config{Rules: map[string]string{"no-such-rule": SeverityOff}}); err == nil {
+		t.Errorf("Lint() with an unknown rule succeeded")
+	}
+}
+
+func TestLintSuppressions(t *testing.T) {
+	s := model.NewResourceProviderSchema(&model.ProviderInfo{Name: "test"}, model.SDKTypeSDK2)
+	s.Resources["test_a"] = model.SchemaInfoWithTimeouts{
+		// Suppressed on the attribute itself, which is still undescribed
+		"own": {Type: "String", Optional: true, Description: "<!-- lint:ignore missing-description -->"},
+		// Suppressed for everything inside the block
+		"block": {Type: "List", Optional: true, IsBlock: true, Description: "A block lint:ignore snake-case,missing-description", Elem: &model.SchemaElement{Type: "SchemaInfo", Info: model.SchemaInfo{
+			"Bad": {Type: "String", Optional: true},
+		}}},
+		// Other rules still apply
+		"other": {Type: "String", Required: true, Computed: true, Description: "Other <!-- lint:ignore missing-description -->"},
+	}
+	s.ResourceHeaders["test_a"] = model.ResourceHeader{Description: "A"}
+	// Suppressed for the resource and all its attributes
+	s.Resources["test_B"] = model.SchemaInfoWithTimeouts{"x": {Type: "String", Optional: true}}
+	s.ResourceHeaders["test_B"] = model.ResourceHeader{Description: "<!-- lint:ignore snake-case,missing-description -->"}
+
+	findings, err := Lint(s, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "error required-computed resource.test_a.other: both Required and Computed"
+	if got := render(findings); got != want {
+		t.Errorf("Lint() =\n%s\nwant\n%s", got, want)
+	}
+}
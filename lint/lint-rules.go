@@ -0,0 +1,218 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/evan-cleary/tf-schema-extractor/model"
+)
+
+// Values of Rule.Severity and of the severities in a Config. Rules
+// configured as SeverityOff do not run.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+	SeverityOff     = "off"
+)
+
+// Rule checks one authoring convention. Each rule checks attributes,
+// resources or both, returning a message per problem found.
+type Rule struct {
+	Name        string
+	Description string
+	// Used unless the Config overrides it
+	Severity string
+
+	attribute func(a *Attribute) []string
+	resource  func(r *Resource) []string
+}
+
+// Attribute is an attribute or block being checked.
+type Attribute struct {
+	Scope string
+	// Empty for the provider
+	Resource string
+	// Dotted path from the root of the resource, e.g. "rule.cidr"
+	Path       string
+	Name       string
+	Definition model.SchemaDefinition
+	// The top-level schema of the resource, against which references
+	// such as ConflictsWith are resolved
+	Root model.SchemaInfo
+	// The schema being linted, against which back-references to
+	// recursive blocks are resolved
+	Schema *model.ResourceProviderSchema
+}
+
+// Resource is a resource or data source being checked.
+type Resource struct {
+	Scope        string
+	Name         string
+	ProviderName string
+	Header       model.ResourceHeader
+	// Whether the schema had a header for the resource at all
+	HasHeader bool
+}
+
+// Rules lists every rule by name.
+var Rules = map[string]*Rule{}
+
+func register(r *Rule) {
+	Rules[r.Name] = r
+}
+
+var snakeCase = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+
+func init() {
+	register(&Rule{
+		Name:        "missing-description",
+		Description: "Attributes, blocks and resources should have a description",
+		Severity:    SeverityWarning,
+		attribute: func(a *Attribute) []string {
+			if strings.TrimSpace(stripSuppressions(a.Definition.Description)) == "" {
+				return []string{"missing description"}
+			}
+			return nil
+		},
+		resource: func(r *Resource) []string {
+			if r.HasHeader && strings.TrimSpace(stripSuppressions(r.Header.Description)) == "" {
+				return []string{"missing description"}
+			}
+			return nil
+		},
+	})
+	register(&Rule{
+		Name:        "required-computed",
+		Description: "Required attributes cannot be computed",
+		Severity:    SeverityError,
+		attribute: func(a *Attribute) []string {
+			if a.Definition.Required && a.Definition.Computed {
+				return []string{"both Required and Computed"}
+			}
+			return nil
+		},
+	})
+	register(&Rule{
+		Name:        "required-default",
+		Description: "Required attributes cannot have a default",
+		Severity:    SeverityError,
+		attribute: func(a *Attribute) []string {
+			if a.Definition.Required && a.Definition.Default != nil {
+				return []string{"Required with a default, which is never used"}
+			}
+			return nil
+		},
+	})
+	register(&Rule{
+		Name:        "unknown-reference",
+		Description: "ConflictsWith, ExactlyOneOf, AtLeastOneOf and RequiredWith must name existing attributes",
+		Severity:    SeverityError,
+		attribute: func(a *Attribute) []string {
+			var messages []string
+			for _, field := range []struct {
+				name  string
+				paths []string
+			}{
+				{"ConflictsWith", a.Definition.ConflictsWith},
+				{"ExactlyOneOf", a.Definition.ExactlyOneOf},
+				{"AtLeastOneOf", a.Definition.AtLeastOneOf},
+				{"RequiredWith", a.Definition.RequiredWith},
+			} {
+				for _, path := range field.paths {
+					if !resolves(a.Schema, a.Root, path) {
+						messages = append(messages, fmt.Sprintf("%s refers to unknown attribute %q", field.name, path))
+					}
+				}
+			}
+			return messages
+		},
+	})
+	register(&Rule{
+		Name:        "items-non-collection",
+		Description: "MaxItems and MinItems only apply to lists and sets",
+		Severity:    SeverityError,
+		attribute: func(a *Attribute) []string {
+			d := a.Definition
+			if d.Type == "List" || d.Type == "Set" || d.MaxItems == 0 && d.MinItems == 0 {
+				return nil
+			}
+			return []string{fmt.Sprintf("MaxItems or MinItems set on type %s", d.Type)}
+		},
+	})
+	register(&Rule{
+		Name:        "snake-case",
+		Description: "Names of attributes, blocks, resources and data sources should be snake_case",
+		Severity:    SeverityWarning,
+		attribute: func(a *Attribute) []string {
+			if !snakeCase.MatchString(a.Name) {
+				return []string{fmt.Sprintf("name %q is not snake_case", a.Name)}
+			}
+			return nil
+		},
+		resource: func(r *Resource) []string {
+			if !snakeCase.MatchString(r.Name) {
+				return []string{fmt.Sprintf("name %q is not snake_case", r.Name)}
+			}
+			return nil
+		},
+	})
+	register(&Rule{
+		Name:        "provider-prefix",
+		Description: "Names of resources and data sources should start with the provider name",
+		Severity:    SeverityWarning,
+		resource: func(r *Resource) []string {
+			if r.ProviderName != "" && !strings.HasPrefix(r.Name, r.ProviderName+"_") {
+				return []string{fmt.Sprintf("name %q does not start with %q", r.Name, r.ProviderName+"_")}
+			}
+			return nil
+		},
+	})
+}
+
+// resolves reports whether path names an attribute of root. Paths are
+// those of the SDK, where list and set elements are numbered, such as
+// "rule.0.cidr".
+func resolves(s *model.ResourceProviderSchema, root model.SchemaInfo, path string) bool {
+	info := root
+	var current *model.SchemaDefinition
+	for _, segment := range strings.Split(path, ".") {
+		if _, err := strconv.Atoi(segment); err == nil && current != nil {
+			continue
+		}
+		if info == nil {
+			return false
+		}
+		d, ok := info[segment]
+		if !ok {
+			return false
+		}
+		current = &d
+		info = nil
+		if d.Elem != nil && d.Elem.Type == model.ElementTypeBackRef {
+			if e, err := model.ResolveBackRef(s, d.Elem.Ref); err == nil {
+				info = e.Info
+			}
+		} else if d.Elem != nil {
+			info = d.Elem.Info
+		}
+	}
+	return current != nil
+}
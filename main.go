@@ -1,3 +1,11 @@
 package main
 
-func main() {}
+import (
+	"os"
+
+	"github.com/evan-cleary/tf-schema-extractor/command"
+)
+
+func main() {
+	os.Exit(command.Run(command.DefaultEnv(), os.Args[1:]))
+}
@@ -0,0 +1,120 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package model
+
+import "sync"
+
+// exportedResource is a resource or data source exported by
+// exportInOrder.
+type exportedResource struct {
+	Info   SchemaInfoWithTimeouts
+	Header ResourceHeader
+}
+
+// exportInOrder calls export for each of names on up to workers
+// goroutines, and passes the results to emit in the order of names on
+// the calling goroutine, so that the merged output does not depend on
+// scheduling. Only a few results per worker are held at a time. With
+// fewer than two workers the names are exported serially. The first
+// error returned by export or emit, in the order of names, stops the
+// export and is returned.
+func exportInOrder(names []string, workers int, export func(name string) (exportedResource, error), emit func(name string, r exportedResource) error) error {
+	if workers < 2 {
+		for _, name := range names {
+			resource, err := export(name)
+			if err != nil {
+				return err
+			}
+			if err := emit(name, resource); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	type result struct {
+		index    int
+		resource exportedResource
+		err      error
+	}
+	jobs := make(chan int)
+	results := make(chan result)
+	done := make(chan struct{})
+	// One token per result that is being exported or waits to be emitted
+	tokens := make(chan struct{}, 2*workers)
+
+	go func() {
+		defer close(jobs)
+		for i := range names {
+			select {
+			case tokens <- struct{}{}:
+			case <-done:
+				return
+			}
+			select {
+			case jobs <- i:
+			case <-done:
+				return
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				resource, err := export(names[i])
+				select {
+				case results <- result{i, resource, err}:
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var err error
+	pending := make(map[int]result)
+	next := 0
+	for r := range results {
+		if err != nil {
+			continue
+		}
+		pending[r.index] = r
+		for err == nil {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			err = r.err
+			if err == nil {
+				err = emit(names[next], r.resource)
+			}
+			next++
+			<-tokens
+		}
+		if err != nil {
+			close(done)
+		}
+	}
+	return err
+}
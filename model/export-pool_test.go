@@ -0,0 +1,138 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package model
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExportInOrder(t *testing.T) {
+	names := make([]string, 50)
+	for i := range names {
+		names[i] = fmt.Sprintf("r%02d", i)
+	}
+	for _, workers := range []int{0, 1, 2, 8} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			var emitted []string
+			err := exportInOrder(names, workers,
+				func(name string) (exportedResource, error) {
+					// Finish out of order
+					time.Sleep(time.Duration(rand.Intn(500)) * time.Microsecond)
+					return exportedResource{Header: ResourceHeader{Description: name}}, nil
+				},
+				func(name string, r exportedResource) error {
+					if r.Header.Description != name {
+						t.Errorf("emitted %s with the result of %s", name, r.Header.Description)
+					}
+					emitted = append(emitted, name)
+					return nil
+				})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(emitted, names) {
+				t.Errorf("emitted %q, want %q", emitted, names)
+			}
+		})
+	}
+}
+
+func TestExportInOrderError(t *testing.T) {
+	names := make([]string, 50)
+	for i := range names {
+		names[i] = fmt.Sprintf("r%02d", i)
+	}
+	exportErr, emitErr := errors.New("export failed"), errors.New("emit failed")
+	for _, workers := range []int{1, 8} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			var emitted int
+			err := exportInOrder(names, workers,
+				func(name string) (exportedResource, error) {
+					// Later failures must not be reported first
+					if name == "r20" || name == "r40" {
+						return exportedResource{}, exportErr
+					}
+					return exportedResource{}, nil
+				},
+				func(name string, r exportedResource) error {
+					emitted++
+					return nil
+				})
+			if err != exportErr || emitted != 20 {
+				t.Errorf("exportInOrder() = %v after %d emitted, want %v after 20", err, emitted, exportErr)
+			}
+
+			emitted = 0
+			err = exportInOrder(names, workers,
+				func(name string) (exportedResource, error) {
+					return exportedResource{}, nil
+				},
+				func(name string, r exportedResource) error {
+					emitted++
+					if name == "r10" {
+						return emitErr
+					}
+					return nil
+				})
+			if err != emitErr || emitted != 11 {
+				t.Errorf("exportInOrder() = %v after %d emitted, want %v after 11", err, emitted, emitErr)
+			}
+		})
+	}
+}
+
+func TestExportWorkers(t *testing.T) {
+	// ValidateFuncs need not be thread-safe, so they must never run
+	// concurrently
+	var running, overlaps, calls int32
+	validateFunc := func() []interface{} {
+		if atomic.AddInt32(&running, 1) > 1 {
+			atomic.AddInt32(&overlaps, 1)
+		}
+		time.Sleep(50 * time.Microsecond)
+		atomic.AddInt32(&running, -1)
+		atomic.AddInt32(&calls, 1)
+		return []interface{}{1, 2}
+	}
+	pi := &ProviderInfo{Name: "test", Revision: "1.0.0"}
+
+	var serial bytes.Buffer
+	if err := (&Exporter{Workers: 1}).Encode(testProvider(100, validateFunc), pi, &serial); err != nil {
+		t.Fatal(err)
+	}
+	for _, workers := range []int{2, 8, 32} {
+		var concurrent bytes.Buffer
+		if err := (&Exporter{Workers: workers}).Encode(testProvider(100, validateFunc), pi, &concurrent); err != nil {
+			t.Fatal(err)
+		}
+		if concurrent.String() != serial.String() {
+			t.Errorf("output with %d workers differs from the serial output", workers)
+		}
+	}
+	if overlaps != 0 {
+		t.Errorf("ValidateFuncs ran concurrently %d times", overlaps)
+	}
+	if calls != 4*100 {
+		t.Errorf("ValidateFuncs called %d times, want %d", calls, 4*100)
+	}
+}
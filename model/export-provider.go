@@ -0,0 +1,233 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package model
+
+import (
+	"io"
+	"sort"
+)
+
+// Adapter exports the parts of a provider that depend on the SDK it is
+// written with. An Exporter drives it and takes care of everything else,
+// such as ordering, concurrency, streaming and nesting, so that the
+// adapter of each SDK only has to convert the SDK's types.
+type Adapter interface {
+	// SDKType returns the ResourceProviderSchema.SDKType of the provider.
+	SDKType() string
+	// ExportProvider exports the configuration schema of the provider.
+	ExportProvider(t *Trace) SchemaInfo
+	// ExportProviderMeta exports the schema of the provider_meta block,
+	// nil if the provider has none.
+	ExportProviderMeta(t *Trace) SchemaInfo
+	// ProviderHeader returns the provider-level metadata other than
+	// ProviderMeta.
+	ProviderHeader() ProviderHeader
+	// Resources and DataSources return the resources and data sources of
+	// the provider by name, as passed to the methods below.
+	Resources() map[string]interface{}
+	DataSources() map[string]interface{}
+	// ExportResource exports the schema of r.
+	ExportResource(r interface{}, t *Trace) SchemaInfo
+	// ExportTimeouts exports the configurable timeouts of r as the
+	// attributes of its "timeouts" block, nil if it has none.
+	ExportTimeouts(r interface{}) SchemaInfo
+	// ResourceHeader returns the resource-level metadata of r.
+	ResourceHeader(r interface{}) ResourceHeader
+}
+
+// Exporter exports providers through their Adapter.
+type Exporter struct {
+	// Number of resources and data sources exported concurrently; they
+	// are exported serially if it is less than 2
+	Workers int
+	// Write nested schemas inline instead of to a definitions table, see
+	// Encoder
+	Inline bool
+	// Maximum nesting depth of schemas, DefaultMaxDepth if 0
+	MaxDepth int
+}
+
+// Export exports the structure of the provider. It fails with a
+// DepthError if the nested schemas of an attribute are deeper than
+// MaxDepth.
+func (x *Exporter) Export(a Adapter, pi *ProviderInfo) (*ResourceProviderSchema, error) {
+	result := NewResourceProviderSchema(pi, a.SDKType())
+	if err := x.exportProvider(a, result); err != nil {
+		return nil, err
+	}
+
+	err := x.walk(a,
+		func(k string, r exportedResource) error {
+			result.Resources[k] = r.Info
+			result.ResourceHeaders[k] = r.Header
+			return nil
+		},
+		func(k string, r exportedResource) error {
+			result.DataSources[k] = r.Info
+			result.DataSourceHeaders[k] = r.Header
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Encode exports the structure of the provider like Export, but writes
+// each resource and data source to w as soon as it is exported, so that
+// only a few of them are held in memory at a time.
+func (x *Exporter) Encode(a Adapter, pi *ProviderInfo, w io.Writer) error {
+	head := NewResourceProviderSchema(pi, a.SDKType())
+	if err := x.exportProvider(a, head); err != nil {
+		return err
+	}
+
+	encoder := NewEncoder(w, head)
+	encoder.Inline = x.Inline
+	err := x.walk(a,
+		func(k string, r exportedResource) error {
+			return encoder.Resource(k, r.Info, &r.Header)
+		},
+		func(k string, r exportedResource) error {
+			return encoder.DataSource(k, r.Info, &r.Header)
+		})
+	if err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// ProviderHeader exports the provider-level metadata of a. It fails like
+// Export.
+func (x *Exporter) ProviderHeader(a Adapter) (ProviderHeader, error) {
+	header := a.ProviderHeader()
+	t := newTrace("provider_meta", nil, x.MaxDepth)
+	if meta := a.ExportProviderMeta(t); len(meta) > 0 {
+		if err := t.Err(); err != nil {
+			return header, err
+		}
+		providerMeta := SingleBlock(meta)
+		header.ProviderMeta = &providerMeta
+	}
+	return header, nil
+}
+
+// StandaloneRoot is the name of a resource exported on its own, which
+// the paths in its back-references and errors start with.
+const StandaloneRoot = "resource"
+
+// ExportResource exports the schema of r, a resource of a. It fails like
+// Export.
+func (x *Exporter) ExportResource(a Adapter, r interface{}) (SchemaInfo, error) {
+	return x.exportResource(a, r, newTrace(StandaloneRoot, r, x.MaxDepth))
+}
+
+// ExportResourceWithTimeouts exports the schema of r including its
+// "timeouts" block. It fails like Export.
+func (x *Exporter) ExportResourceWithTimeouts(a Adapter, r interface{}) (SchemaInfoWithTimeouts, error) {
+	return x.exportResourceWithTimeouts(a, r, newTrace(StandaloneRoot, r, x.MaxDepth))
+}
+
+func (x *Exporter) exportResourceWithTimeouts(a Adapter, r interface{}, t *Trace) (SchemaInfoWithTimeouts, error) {
+	info, err := x.exportResource(a, r, t)
+	if err != nil {
+		return nil, err
+	}
+	result := make(SchemaInfoWithTimeouts)
+	for nk, nv := range info {
+		result[nk] = nv
+	}
+	// Like the SDK, a schema that declares its own "timeouts" wins.
+	if _, ok := result[TimeoutsConfigKey]; !ok {
+		if timeouts := a.ExportTimeouts(r); len(timeouts) > 0 {
+			result[TimeoutsConfigKey] = SingleBlock(timeouts)
+		}
+	}
+	return result, nil
+}
+
+func (x *Exporter) exportResource(a Adapter, r interface{}, t *Trace) (SchemaInfo, error) {
+	result := a.ExportResource(r, t)
+	return result, t.Err()
+}
+
+// exportProvider exports the provider's own schema and header into s.
+func (x *Exporter) exportProvider(a Adapter, s *ResourceProviderSchema) error {
+	t := newTrace("provider", nil, x.MaxDepth)
+	s.Provider = a.ExportProvider(t)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	header, err := x.ProviderHeader(a)
+	if err != nil {
+		return err
+	}
+	s.ProviderHeader = header
+	return nil
+}
+
+// walk exports the resources and then the data sources of the provider
+// on Workers goroutines, passing each to resource or dataSource in order
+// of their names.
+func (x *Exporter) walk(a Adapter, resource, dataSource func(k string, r exportedResource) error) error {
+	// Provider functions such as ValidateFuncs need not be thread-safe, so
+	// while exporting concurrently all of them are called on one
+	// goroutine, see Trace.Call.
+	var calls chan func()
+	if x.Workers > 1 {
+		calls = make(chan func())
+		defer close(calls)
+		go func() {
+			for call := range calls {
+				call()
+			}
+		}()
+	}
+
+	for _, group := range []struct {
+		resources map[string]interface{}
+		// Prefix of the paths in errors
+		prefix string
+		emit   func(k string, r exportedResource) error
+	}{
+		{a.Resources(), "", resource},
+		{a.DataSources(), "data.", dataSource},
+	} {
+		resources, prefix := group.resources, group.prefix
+		export := func(k string) (exportedResource, error) {
+			t := newTrace(prefix+k, resources[k], x.MaxDepth)
+			t.calls = calls
+			info, err := x.exportResourceWithTimeouts(a, resources[k], t)
+			if err != nil {
+				return exportedResource{}, err
+			}
+			return exportedResource{Info: info, Header: a.ResourceHeader(resources[k])}, nil
+		}
+		if err := exportInOrder(sortedKeys(resources), x.Workers, export, group.emit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedKeys(resources map[string]interface{}) []string {
+	names := make([]string, 0, len(resources))
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
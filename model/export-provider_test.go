@@ -0,0 +1,262 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/evan-cleary/tf-schema-extractor/validators"
+)
+
+// testAdapter exports a provider made of testResources, standing in for
+// the adapter of an SDK.
+type testAdapter struct {
+	provider     *testResource
+	providerMeta *testResource
+	resources    map[string]*testResource
+	dataSources  map[string]*testResource
+}
+
+type testResource struct {
+	attributes map[string]*testAttribute
+	timeouts   SchemaInfo
+	header     ResourceHeader
+}
+
+type testAttribute struct {
+	// The definition without Elem and Default
+	definition SchemaDefinition
+	// The nested block, which may be shared with other attributes
+	block *testResource
+	// Probed for the allowed values like a ValidateFunc
+	validateFunc func() []interface{}
+}
+
+func (a *testAdapter) SDKType() string {
+	return SDKTypeSDK2
+}
+
+func (a *testAdapter) ExportProvider(t *Trace) SchemaInfo {
+	return a.export(a.provider, t)
+}
+
+func (a *testAdapter) ExportProviderMeta(t *Trace) SchemaInfo {
+	if a.providerMeta == nil {
+		return nil
+	}
+	return a.export(a.providerMeta, t)
+}
+
+func (a *testAdapter) ProviderHeader() ProviderHeader {
+	return ProviderHeader{Configurable: true}
+}
+
+func (a *testAdapter) Resources() map[string]interface{} {
+	return resourceMap(a.resources)
+}
+
+func (a *testAdapter) DataSources() map[string]interface{} {
+	return resourceMap(a.dataSources)
+}
+
+func resourceMap(resources map[string]*testResource) map[string]interface{} {
+	result := make(map[string]interface{}, len(resources))
+	for name, r := range resources {
+		result[name] = r
+	}
+	return result
+}
+
+func (a *testAdapter) ExportResource(r interface{}, t *Trace) SchemaInfo {
+	return a.export(r.(*testResource), t)
+}
+
+func (a *testAdapter) ExportTimeouts(r interface{}) SchemaInfo {
+	return r.(*testResource).timeouts
+}
+
+func (a *testAdapter) ResourceHeader(r interface{}) ResourceHeader {
+	return r.(*testResource).header
+}
+
+func (a *testAdapter) export(r *testResource, t *Trace) SchemaInfo {
+	names := make([]string, 0, len(r.attributes))
+	for name := range r.attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	info := make(SchemaInfo)
+	for _, name := range names {
+		attribute := r.attributes[name]
+		t.Attribute(name, attribute)
+		d := attribute.definition
+		if attribute.block != nil {
+			d.Elem = t.Block(attribute.block, func() SchemaInfo {
+				return a.export(attribute.block, t)
+			})
+		}
+		if attribute.validateFunc != nil {
+			t.Call(func() {
+				d.Validation = &validators.Constraints{AllowedValues: attribute.validateFunc()}
+			})
+		}
+		t.EndAttribute()
+		info[name] = d
+	}
+	return info
+}
+
+// testProvider returns a provider with n resources that share a nested
+// block, and a resource that nests itself. validateFunc is the
+// ValidateFunc of every resource's "count" attribute.
+func testProvider(n int, validateFunc func() []interface{}) *testAdapter {
+	tags := &testResource{attributes: map[string]*testAttribute{
+		"key":   {definition: SchemaDefinition{Type: "String", Required: true}},
+		"value": {definition: SchemaDefinition{Type: "String", Optional: true}},
+	}}
+	a := &testAdapter{
+		provider: &testResource{attributes: map[string]*testAttribute{
+			"region": {definition: SchemaDefinition{Type: "String", Optional: true, Description: "The region"}},
+		}},
+		providerMeta: &testResource{attributes: map[string]*testAttribute{
+			"module_name": {definition: SchemaDefinition{Type: "String", Optional: true}},
+		}},
+		resources:   make(map[string]*testResource),
+		dataSources: make(map[string]*testResource),
+	}
+	for i := 0; i < n; i++ {
+		a.resources[fmt.Sprintf("test_r%03d", i)] = &testResource{
+			attributes: map[string]*testAttribute{
+				"name":  {definition: SchemaDefinition{Type: "String", Optional: true, ConflictsWith: []string{"prefix", "id"}}},
+				"count": {definition: SchemaDefinition{Type: "Int", Optional: true}, validateFunc: validateFunc},
+				"tags":  {definition: SchemaDefinition{Type: "Set", Optional: true, IsBlock: true, ConfigImplicitMode: "Block"}, block: tags},
+			},
+			timeouts: SchemaInfo{TimeoutCreate: TimeoutAttribute(time.Duration(i+1) * time.Minute)},
+			header:   NewResourceHeader(true, true, i%2 == 0, true),
+		}
+	}
+	recursive := &testResource{attributes: map[string]*testAttribute{
+		"name": {definition: SchemaDefinition{Type: "String", Required: true}},
+	}}
+	recursive.attributes["child"] = &testAttribute{
+		definition: SchemaDefinition{Type: "List", Optional: true, IsBlock: true, ConfigImplicitMode: "Block"},
+		block:      recursive,
+	}
+	a.resources["test_tree"] = recursive
+	a.dataSources["test_tags"] = &testResource{
+		attributes: map[string]*testAttribute{
+			"tags": {definition: SchemaDefinition{Type: "Set", Computed: true, IsBlock: true, ConfigImplicitMode: "Block"}, block: tags},
+		},
+		header: ResourceHeader{Operations: []string{OperationRead}, ReadOnly: true},
+	}
+	return a
+}
+
+func TestEncode(t *testing.T) {
+	pi := &ProviderInfo{Name: "test", Revision: "1.0.0"}
+	for _, inline := range []bool{false, true} {
+		t.Run(fmt.Sprintf("inline %t", inline), func(t *testing.T) {
+			x := &Exporter{Inline: inline}
+			var encoded bytes.Buffer
+			if err := x.Encode(testProvider(20, nil), pi, &encoded); err != nil {
+				t.Fatal(err)
+			}
+
+			s, err := x.Export(testProvider(20, nil), pi)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var written bytes.Buffer
+			write := Write
+			if inline {
+				write = WriteInline
+			}
+			if err = write(s, &written); err != nil {
+				t.Fatal(err)
+			}
+			if encoded.String() != written.String() {
+				t.Errorf("Encode() =\n%s\nwant\n%s", encoded.String(), written.String())
+			}
+		})
+	}
+}
+
+func TestWriteInlineFormat(t *testing.T) {
+	// The format of schema version 2, before nested schemas were
+	// deduplicated and resources streamed
+	s, err := new(Exporter).Export(testProvider(3, nil), &ProviderInfo{Name: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written bytes.Buffer
+	if err = WriteInline(s, &written); err != nil {
+		t.Fatal(err)
+	}
+	want, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written.String() != string(want) {
+		t.Errorf("WriteInline() =\n%s\nwant\n%s", written.String(), want)
+	}
+}
+
+func TestEncoderOrder(t *testing.T) {
+	info := SchemaInfoWithTimeouts{"id": {Type: "String", Computed: true}}
+	tests := []struct {
+		name   string
+		encode func(e *Encoder) error
+	}{
+		{"resource out of order", func(e *Encoder) error {
+			if err := e.Resource("b", info, nil); err != nil {
+				return err
+			}
+			return e.Resource("a", info, nil)
+		}},
+		{"resource encoded twice", func(e *Encoder) error {
+			if err := e.Resource("a", info, nil); err != nil {
+				return err
+			}
+			return e.Resource("a", info, nil)
+		}},
+		{"resource after data source", func(e *Encoder) error {
+			if err := e.DataSource("a", info, nil); err != nil {
+				return err
+			}
+			return e.Resource("b", info, nil)
+		}},
+		{"closed twice", func(e *Encoder) error {
+			if err := e.Close(); err != nil {
+				return err
+			}
+			return e.Close()
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e := NewEncoder(new(bytes.Buffer), NewResourceProviderSchema(&ProviderInfo{Name: "test"}, ""))
+			if err := test.encode(e); err == nil {
+				t.Errorf("no error")
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultMaxDepth is the maximum nesting depth of schemas a Trace allows
+// if none is configured. Providers nest far less deeply; deeper nesting
+// usually comes from a schema that refers to itself through a copy.
+const DefaultMaxDepth = 32
+
+// ElementTypeBackRef is the SchemaElement.Type of a nested schema that
+// is already being exported further up, such as the Elem of a resource
+// that refers to itself. Its Ref is the absolute path of the schema it
+// repeats, see ResolveBackRef.
+const ElementTypeBackRef = "SchemaBackRef"
+
+// DepthError reports an attribute whose nested schemas exceed the
+// maximum depth.
+type DepthError struct {
+	// Path of the attribute, starting with the resource
+	Path     string
+	MaxDepth int
+}
+
+func (e *DepthError) Error() string {
+	return fmt.Sprintf("%s: schemas nested more than %d levels deep", e.Path, e.MaxDepth)
+}
+
+// Trace follows an adapter through the nested schemas of a resource, so
+// that a schema referring to itself is exported once and referred back
+// to instead of being recursed into forever, and so that overly deep
+// nesting fails with the path where it happened.
+type Trace struct {
+	maxDepth int
+	err      error
+	// Path segments from the root: the root's name, attribute names and
+	// "*" for the elements of a collection
+	path []string
+	// Schemas being exported, outermost first
+	nodes []traceNode
+	// Number of nested Elem schemas being exported
+	depth int
+	// Set while exporting concurrently, see Call
+	calls chan func()
+}
+
+type traceNode struct {
+	node interface{}
+	// Absolute path of the node, as used by back-references
+	path string
+}
+
+// newTrace returns a Trace for node, the resource called root, such as
+// "aws_instance", "data.aws_ami" or "provider". A nil node stands for a
+// schema without an SDK type of its own, such as the provider's. A
+// maxDepth of 0 means DefaultMaxDepth.
+func newTrace(root string, node interface{}, maxDepth int) *Trace {
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDepth
+	}
+	t := &Trace{maxDepth: maxDepth, path: []string{root}}
+	if node == nil {
+		node = t
+	}
+	t.nodes = []traceNode{{node: node, path: root}}
+	return t
+}
+
+// Attribute starts the export of the attribute called name, whose schema
+// is node, and EndAttribute ends it.
+func (t *Trace) Attribute(name string, node interface{}) {
+	t.path = append(t.path, name)
+	t.nodes = append(t.nodes, traceNode{node: node, path: t.Path()})
+}
+
+func (t *Trace) EndAttribute() {
+	t.path = t.path[:len(t.path)-1]
+	t.nodes = t.nodes[:len(t.nodes)-1]
+}
+
+// Block exports node, the nested resource that is the Elem of the
+// current attribute, as a SchemaInfo element with export. If node is
+// already being exported further up, it is referred back to instead.
+// The result of a failed trace is discarded.
+func (t *Trace) Block(node interface{}, export func() SchemaInfo) *SchemaElement {
+	backRef, descend := t.enter(node)
+	if !descend {
+		return &SchemaElement{Type: ElementTypeBackRef, Ref: backRef}
+	}
+	defer t.exit()
+	return &SchemaElement{Type: "SchemaInfo", Info: export()}
+}
+
+// Elements exports node, the nested schema that is the Elem of the
+// current attribute, as a SchemaElements element with export, referring
+// back to it like Block.
+func (t *Trace) Elements(node interface{}, export func() SchemaDefinition) *SchemaElement {
+	backRef, descend := t.enter(node)
+	if !descend {
+		return &SchemaElement{Type: ElementTypeBackRef, Ref: backRef}
+	}
+	defer t.exit()
+	t.path = append(t.path, "*")
+	elements := export()
+	t.path = t.path[:len(t.path)-1]
+	return &SchemaElement{Type: "SchemaElements", ElementsType: elements.Type, Elements: &elements}
+}
+
+// enter starts the export of node, the Elem of the current attribute,
+// and reports whether to descend into it. If node is already being
+// exported further up, it returns the path of that schema, which the
+// caller should refer back to. If descending would exceed the maximum
+// depth, the trace fails instead. exit must be called if and only if
+// enter returns true.
+func (t *Trace) enter(node interface{}) (backRef string, descend bool) {
+	if t.err != nil {
+		return "", false
+	}
+	for _, n := range t.nodes {
+		if n.node == node {
+			return n.path, false
+		}
+	}
+	if t.depth >= t.maxDepth {
+		t.err = &DepthError{Path: t.Path(), MaxDepth: t.maxDepth}
+		return "", false
+	}
+	t.depth++
+	t.nodes = append(t.nodes, traceNode{node: node, path: t.Path() + ".*"})
+	return "", true
+}
+
+func (t *Trace) exit() {
+	t.depth--
+	t.nodes = t.nodes[:len(t.nodes)-1]
+}
+
+// Call calls f, a function of the provider such as a ValidateFunc.
+// Provider functions need not be thread-safe, so while resources are
+// exported concurrently all of them are called on one goroutine.
+func (t *Trace) Call(f func()) {
+	if t.calls == nil {
+		f()
+		return
+	}
+	done := make(chan struct{})
+	t.calls <- func() {
+		defer close(done)
+		f()
+	}
+	<-done
+}
+
+// Path returns the path of the current attribute, starting with the
+// resource.
+func (t *Trace) Path() string {
+	return strings.Join(t.path, ".")
+}
+
+// Err returns the DepthError the trace failed with, if any.
+func (t *Trace) Err() error {
+	return t.err
+}
@@ -0,0 +1,97 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package model
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// block returns a List block attribute nesting r.
+func block(r *testResource) *testAttribute {
+	return &testAttribute{
+		definition: SchemaDefinition{Type: "List", Optional: true, IsBlock: true, ConfigImplicitMode: "Block"},
+		block:      r,
+	}
+}
+
+// chain returns a resource whose "n" block is nested depth levels deep.
+func chain(depth int) *testResource {
+	r := &testResource{attributes: map[string]*testAttribute{
+		"value": {definition: SchemaDefinition{Type: "String", Optional: true}},
+	}}
+	for i := 0; i < depth; i++ {
+		r = &testResource{attributes: map[string]*testAttribute{"n": block(r)}}
+	}
+	return r
+}
+
+func TestTraceBackRef(t *testing.T) {
+	tree := &testResource{attributes: map[string]*testAttribute{
+		"name": {definition: SchemaDefinition{Type: "String", Required: true}},
+	}}
+	tree.attributes["child"] = block(tree)
+	a := &testAdapter{
+		provider:  &testResource{},
+		resources: map[string]*testResource{"p_tree": {attributes: map[string]*testAttribute{"root": block(tree)}}},
+	}
+	s, err := (&Exporter{}).Export(a, &ProviderInfo{Name: "p"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := s.Resources["p_tree"]["root"]
+	child := root.Elem.Info["child"]
+	if want := (&SchemaElement{Type: ElementTypeBackRef, Ref: "p_tree.root.*"}); !reflect.DeepEqual(child.Elem, want) {
+		t.Fatalf("child.Elem = %+v, want %+v", child.Elem, want)
+	}
+	resolved, err := ResolveBackRef(s, child.Elem.Ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(resolved, root.Elem) {
+		t.Errorf("ResolveBackRef(%q) = %+v, want %+v", child.Elem.Ref, resolved, root.Elem)
+	}
+}
+
+func TestTraceMaxDepth(t *testing.T) {
+	a := &testAdapter{
+		provider:  &testResource{},
+		resources: map[string]*testResource{"p_chain": chain(DefaultMaxDepth + 9)},
+	}
+	_, err := (&Exporter{}).Export(a, &ProviderInfo{Name: "p"})
+	var depthErr *DepthError
+	if !errors.As(err, &depthErr) {
+		t.Fatalf("Export() error = %v, want a DepthError", err)
+	}
+	want := "p_chain" + strings.Repeat(".n", DefaultMaxDepth+1)
+	if depthErr.Path != want || depthErr.MaxDepth != DefaultMaxDepth {
+		t.Errorf("DepthError = %+v, want path %s", depthErr, want)
+	}
+
+	s, err := (&Exporter{MaxDepth: DefaultMaxDepth + 9}).Export(a, &ProviderInfo{Name: "p"})
+	if err != nil {
+		t.Fatalf("Export() with MaxDepth %d: %s", DefaultMaxDepth+9, err)
+	}
+	info := SchemaInfo(s.Resources["p_chain"])
+	for i := 0; i < DefaultMaxDepth+9; i++ {
+		info = info["n"].Elem.Info
+	}
+	if _, ok := info["value"]; !ok {
+		t.Errorf("innermost block = %+v, want the value attribute", info)
+	}
+}
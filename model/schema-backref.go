@@ -0,0 +1,81 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ResolveBackRef returns the element that a back-reference in s stands
+// for, see ElementTypeBackRef. Its ref is the absolute path of a schema
+// further up: the name of a resource, "data." and the name of a data
+// source, "provider" or "provider_meta", followed by attribute names
+// and "*" for the Elem of an attribute. A path that ends with the name
+// of an attribute stands for a collection of elements like it.
+func ResolveBackRef(s *ResourceProviderSchema, ref string) (*SchemaElement, error) {
+	segments := strings.Split(ref, ".")
+	var info SchemaInfo
+	switch {
+	case segments[0] == "provider":
+		info = s.Provider
+	case segments[0] == "provider_meta":
+		if meta := s.ProviderHeader.ProviderMeta; meta != nil && meta.Elem != nil {
+			info = meta.Elem.Info
+		}
+	case segments[0] == "data" && len(segments) > 1:
+		info = SchemaInfo(s.DataSources[segments[1]])
+		segments = segments[1:]
+	default:
+		info = SchemaInfo(s.Resources[segments[0]])
+	}
+	if info == nil {
+		return nil, fmt.Errorf("back-reference to unknown schema %q", ref)
+	}
+
+	elem := &SchemaElement{Type: "SchemaInfo", Info: info}
+	var d *SchemaDefinition
+	for _, segment := range segments[1:] {
+		if segment == "*" {
+			if d == nil || d.Elem == nil {
+				return nil, fmt.Errorf("back-reference to unknown schema %q", ref)
+			}
+			elem, d = d.Elem, nil
+			if elem.Type == "SchemaElements" {
+				d = elem.Elements
+			}
+			continue
+		}
+		if d != nil {
+			elem = d.Elem
+		}
+		if elem == nil || elem.Type != "SchemaInfo" {
+			return nil, fmt.Errorf("back-reference to unknown schema %q", ref)
+		}
+		attribute, ok := elem.Info[segment]
+		if !ok {
+			return nil, fmt.Errorf("back-reference to unknown schema %q", ref)
+		}
+		d = &attribute
+	}
+	if d != nil && (len(segments) == 1 || segments[len(segments)-1] != "*") {
+		elem = &SchemaElement{Type: "SchemaElements", ElementsType: d.Type, Elements: d}
+	}
+	if elem == nil || elem.Type == ElementTypeBackRef {
+		return nil, fmt.Errorf("back-reference to unresolved schema %q", ref)
+	}
+	return elem, nil
+}
@@ -0,0 +1,201 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+)
+
+// Canonicalize brings s into its canonical form in place, so that equal
+// schemas encode to equal bytes however they were built: attribute
+// references and state upgraders are sorted and numbers in defaults and
+// allowed values are normalized. Map keys are already sorted by the
+// JSON encoding.
+func Canonicalize(s *ResourceProviderSchema) {
+	// Missing maps are written as empty ones
+	if s.Provider == nil {
+		s.Provider = make(SchemaInfo)
+	}
+	if s.Resources == nil {
+		s.Resources = make(map[string]SchemaInfoWithTimeouts)
+	}
+	if s.DataSources == nil {
+		s.DataSources = make(map[string]SchemaInfoWithTimeouts)
+	}
+	canonicalInfo(s.Provider)
+	if s.ProviderHeader.ProviderMeta != nil {
+		canonicalDefinition(s.ProviderHeader.ProviderMeta)
+	}
+	for _, info := range s.Resources {
+		canonicalInfo(SchemaInfo(info))
+	}
+	for _, info := range s.DataSources {
+		canonicalInfo(SchemaInfo(info))
+	}
+	for _, headers := range []map[string]ResourceHeader{s.ResourceHeaders, s.DataSourceHeaders} {
+		for name, header := range headers {
+			sort.Ints(header.StateUpgraders)
+			headers[name] = header
+		}
+	}
+}
+
+func canonicalInfo(info SchemaInfo) {
+	for name, d := range info {
+		canonicalDefinition(&d)
+		info[name] = d
+	}
+}
+
+func canonicalDefinition(d *SchemaDefinition) {
+	d.ComputedWhen = sortedStrings(d.ComputedWhen)
+	d.ConflictsWith = sortedStrings(d.ConflictsWith)
+	d.ExactlyOneOf = sortedStrings(d.ExactlyOneOf)
+	d.AtLeastOneOf = sortedStrings(d.AtLeastOneOf)
+	d.RequiredWith = sortedStrings(d.RequiredWith)
+	if d.Default != nil {
+		d.Default.Value = canonicalValue(d.Default.Value)
+	}
+	if d.Validation != nil {
+		for i, v := range d.Validation.AllowedValues {
+			d.Validation.AllowedValues[i] = canonicalValue(v)
+		}
+	}
+	if d.Elem != nil {
+		if d.Elem.Elements != nil {
+			canonicalDefinition(d.Elem.Elements)
+		}
+		canonicalInfo(d.Elem.Info)
+	}
+}
+
+// sortedStrings returns paths sorted. Exported paths are shared with the
+// provider's own schema, so they are sorted in a copy.
+func sortedStrings(paths []string) []string {
+	if sort.StringsAreSorted(paths) {
+		return paths
+	}
+	result := append([]string(nil), paths...)
+	sort.Strings(result)
+	return result
+}
+
+// canonicalValue normalizes the numbers of a JSON value: json.Number, as
+// read by Load, becomes an int64 if it is an integer and a float64
+// otherwise, whose encoding is unique, and negative zero becomes zero.
+func canonicalValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return canonicalValue(f)
+		}
+	case float64:
+		if v == 0 {
+			return float64(0)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = canonicalValue(v[i])
+		}
+	case map[string]interface{}:
+		for k := range v {
+			v[k] = canonicalValue(v[k])
+		}
+	}
+	return value
+}
+
+// Fingerprints are content hashes of a schema's parts. A hash changes
+// exactly when the canonical form of its part does, so comparing them
+// detects changes without comparing the schemas themselves.
+type Fingerprints struct {
+	// Hash of everything but the provider's version
+	Provider string `json:"provider"`
+	// Hashes of the provider's attributes by dotted path
+	ProviderAttributes map[string]string              `json:"provider-attributes"`
+	Resources          map[string]ResourceFingerprint `json:"resources"`
+	DataSources        map[string]ResourceFingerprint `json:"data-sources"`
+}
+
+// ResourceFingerprint holds the hashes of a resource or data source.
+type ResourceFingerprint struct {
+	// Hash of the schema and header
+	Hash string `json:"hash"`
+	// Hashes of the attributes and blocks by dotted path, such as
+	// "rule" and "rule.cidr"
+	Attributes map[string]string `json:"attributes"`
+}
+
+// Fingerprint canonicalizes s and returns the hashes of its parts.
+func Fingerprint(s *ResourceProviderSchema) *Fingerprints {
+	Canonicalize(s)
+	versionless := *s
+	versionless.Version = ""
+	result := &Fingerprints{
+		Provider:           hash(&versionless),
+		ProviderAttributes: make(map[string]string),
+		Resources:          make(map[string]ResourceFingerprint),
+		DataSources:        make(map[string]ResourceFingerprint),
+	}
+	attributeHashes(result.ProviderAttributes, "", s.Provider)
+	for _, group := range []struct {
+		schemas      map[string]SchemaInfoWithTimeouts
+		headers      map[string]ResourceHeader
+		fingerprints map[string]ResourceFingerprint
+	}{
+		{s.Resources, s.ResourceHeaders, result.Resources},
+		{s.DataSources, s.DataSourceHeaders, result.DataSources},
+	} {
+		for name, info := range group.schemas {
+			fingerprint := ResourceFingerprint{
+				Hash: hash(struct {
+					Schema SchemaInfoWithTimeouts
+					Header ResourceHeader
+				}{info, group.headers[name]}),
+				Attributes: make(map[string]string),
+			}
+			attributeHashes(fingerprint.Attributes, "", SchemaInfo(info))
+			group.fingerprints[name] = fingerprint
+		}
+	}
+	return result
+}
+
+func attributeHashes(hashes map[string]string, prefix string, info SchemaInfo) {
+	for name, d := range info {
+		hashes[prefix+name] = hash(d)
+		if d.Elem != nil && d.Elem.Info != nil {
+			attributeHashes(hashes, prefix+name+".", d.Elem.Info)
+		}
+	}
+}
+
+// hash returns the hex SHA-256 of the compact JSON encoding of v.
+func hash(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		// Only values without a JSON form fail, which Load cannot produce
+		panic(err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,152 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// canonicalFixture returns a small schema whose attribute references,
+// state upgraders and numbers are not in canonical form.
+func canonicalFixture(conflicts []string, limit interface{}) *ResourceProviderSchema {
+	s := NewResourceProviderSchema(&ProviderInfo{Name: "test", Revision: "1.0.0"}, SDKTypeSDK2)
+	s.Provider["region"] = SchemaDefinition{Type: "String", Optional: true}
+	s.Resources["test_thing"] = SchemaInfoWithTimeouts{
+		"a": {Type: "String", Optional: true, ConflictsWith: conflicts},
+		"b": {Type: "Int", Optional: true, Default: &SchemaDefault{Source: DefaultSourceStatic, Value: limit}},
+		"c": {Type: "String", Optional: true},
+		"rule": {Type: "List", Optional: true, IsBlock: true, Elem: &SchemaElement{Type: "SchemaInfo", Info: SchemaInfo{
+			"cidr": {Type: "String", Required: true, ExactlyOneOf: []string{"rule.0.ipv6", "rule.0.cidr"}},
+			"ipv6": {Type: "String", Optional: true},
+		}}},
+	}
+	s.ResourceHeaders["test_thing"] = ResourceHeader{SchemaVersion: 3, StateUpgraders: []int{2, 0, 1}}
+	s.DataSources["test_info"] = SchemaInfoWithTimeouts{"id": {Type: "String", Computed: true}}
+	return s
+}
+
+func TestCanonicalize(t *testing.T) {
+	conflicts := []string{"c", "b"}
+	s := canonicalFixture(conflicts, json.Number("10"))
+	Canonicalize(s)
+
+	thing := s.Resources["test_thing"]
+	if got, want := thing["a"].ConflictsWith, []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ConflictsWith = %q, want %q", got, want)
+	}
+	if got, want := thing["rule"].Elem.Info["cidr"].ExactlyOneOf, []string{"rule.0.cidr", "rule.0.ipv6"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("nested ExactlyOneOf = %q, want %q", got, want)
+	}
+	if got, want := s.ResourceHeaders["test_thing"].StateUpgraders, []int{0, 1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("StateUpgraders = %v, want %v", got, want)
+	}
+	if got, want := thing["b"].Default.Value, interface{}(int64(10)); got != want {
+		t.Errorf("Default = %#v, want %#v", got, want)
+	}
+	// The slices are shared with the provider's own schema
+	if want := []string{"c", "b"}; !reflect.DeepEqual(conflicts, want) {
+		t.Errorf("Canonicalize sorted the provider's slice to %q", conflicts)
+	}
+}
+
+func TestCanonicalValue(t *testing.T) {
+	negativeZero := 0.0
+	negativeZero = -negativeZero
+	tests := []struct {
+		value interface{}
+		want  interface{}
+	}{
+		{json.Number("42"), int64(42)},
+		{json.Number("-1"), int64(-1)},
+		{json.Number("1.5"), 1.5},
+		{json.Number("1.0"), 1.0},
+		{json.Number("1e3"), 1000.0},
+		{negativeZero, 0.0},
+		{"1", "1"},
+		{[]interface{}{json.Number("1"), json.Number("0.5")}, []interface{}{int64(1), 0.5}},
+		{map[string]interface{}{"n": json.Number("2")}, map[string]interface{}{"n": int64(2)}},
+	}
+	for _, test := range tests {
+		got := canonicalValue(test.value)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("canonicalValue(%#v) = %#v, want %#v", test.value, got, test.want)
+		}
+		if f, ok := got.(float64); ok && f == 0 && 1/f < 0 {
+			t.Errorf("canonicalValue(%#v) kept negative zero", test.value)
+		}
+	}
+}
+
+func TestWriteCanonical(t *testing.T) {
+	var first, second bytes.Buffer
+	if err := Write(canonicalFixture([]string{"c", "b"}, 10), &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := Write(canonicalFixture([]string{"b", "c"}, json.Number("10")), &second); err != nil {
+		t.Fatal(err)
+	}
+	if first.String() != second.String() {
+		t.Errorf("equal schemas written differently:\n%s\n%s", first.String(), second.String())
+	}
+
+	// Writing what Load read reproduces the input
+	s, err := Load(bytes.NewReader(first.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var again bytes.Buffer
+	if err = Write(s, &again); err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != first.String() {
+		t.Errorf("Write(Load()) =\n%s\nwant\n%s", again.String(), first.String())
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	base := Fingerprint(canonicalFixture([]string{"c", "b"}, 10))
+	if again := Fingerprint(canonicalFixture([]string{"b", "c"}, json.Number("10"))); !reflect.DeepEqual(again, base) {
+		t.Errorf("fingerprints of equal schemas differ:\n%+v\n%+v", base, again)
+	}
+
+	s := canonicalFixture([]string{"c", "b"}, 10)
+	s.Version = "2.0.0"
+	if got := Fingerprint(s); !reflect.DeepEqual(got, base) {
+		t.Errorf("fingerprints depend on the provider version")
+	}
+
+	s = canonicalFixture([]string{"c", "b"}, 10)
+	s.Resources["test_thing"]["rule"].Elem.Info["ipv6"] = SchemaDefinition{Type: "String", Required: true}
+	changed := Fingerprint(s)
+	if changed.Provider == base.Provider {
+		t.Errorf("provider hash did not change")
+	}
+	for path, hash := range base.Resources["test_thing"].Attributes {
+		wantChange := path == "rule" || path == "rule.ipv6"
+		if got := changed.Resources["test_thing"].Attributes[path]; (got != hash) != wantChange {
+			t.Errorf("hash of %s changed: %t, want %t", path, got != hash, wantChange)
+		}
+	}
+	if changed.Resources["test_thing"].Hash == base.Resources["test_thing"].Hash {
+		t.Errorf("hash of test_thing did not change")
+	}
+	if !reflect.DeepEqual(changed.DataSources, base.DataSources) || !reflect.DeepEqual(changed.ProviderAttributes, base.ProviderAttributes) {
+		t.Errorf("hashes of unchanged parts changed")
+	}
+}
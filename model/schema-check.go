@@ -0,0 +1,128 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package model
+
+import (
+	"fmt"
+	"sort"
+)
+
+var valueTypes = map[string]bool{
+	"Bool":   true,
+	"Int":    true,
+	"Float":  true,
+	"String": true,
+	"List":   true,
+	"Set":    true,
+	"Map":    true,
+}
+
+// Check reports where s does not conform to the format written by
+// Write, such as a loaded file produced by another tool. Each problem
+// is prefixed with the path of the offending element.
+func Check(s *ResourceProviderSchema) []string {
+	var problems []string
+	report := func(path, format string, a ...interface{}) {
+		problems = append(problems, path+": "+fmt.Sprintf(format, a...))
+	}
+
+	if s.SchemaVersion != SchemaVersionInline {
+		report(".schema_version", "unsupported version %q", s.SchemaVersion)
+	}
+	if s.Type != "provider" {
+		report("type", "expected \"provider\", got %q", s.Type)
+	}
+	if s.Name == "" {
+		report("name", "missing provider name")
+	}
+	checkInfo(s, "provider", s.Provider, report)
+	if s.ProviderHeader.ProviderMeta != nil {
+		checkDefinition(s, "provider-header.ProviderMeta", *s.ProviderHeader.ProviderMeta, report)
+	}
+	checkResources(s, "resources", s.Resources, s.ResourceHeaders, report)
+	checkResources(s, "data-sources", s.DataSources, s.DataSourceHeaders, report)
+	return problems
+}
+
+type reportFunc func(path, format string, a ...interface{})
+
+func checkResources(s *ResourceProviderSchema, path string, resources map[string]SchemaInfoWithTimeouts, headers map[string]ResourceHeader, report reportFunc) {
+	names := make([]string, 0, len(resources))
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		checkInfo(s, path+"."+name, SchemaInfo(resources[name]), report)
+		if _, ok := headers[name]; !ok && len(headers) > 0 {
+			report(path+"."+name, "missing header")
+		}
+	}
+	var orphans []string
+	for name := range headers {
+		if _, ok := resources[name]; !ok {
+			orphans = append(orphans, name)
+		}
+	}
+	sort.Strings(orphans)
+	for _, name := range orphans {
+		report(path+"."+name, "header without schema")
+	}
+}
+
+func checkInfo(s *ResourceProviderSchema, path string, info SchemaInfo, report reportFunc) {
+	names := make([]string, 0, len(info))
+	for name := range info {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		checkDefinition(s, path+"."+name, info[name], report)
+	}
+}
+
+func checkDefinition(s *ResourceProviderSchema, path string, d SchemaDefinition, report reportFunc) {
+	if !valueTypes[d.Type] {
+		report(path, "unknown type %q", d.Type)
+	}
+	if d.Default != nil && d.Default.Source != DefaultSourceStatic && d.Default.Source != DefaultSourceFunc {
+		report(path, "unknown default source %q", d.Default.Source)
+	}
+	if d.Nesting != "" && d.Nesting != NestingSingle && d.Nesting != NestingMap {
+		report(path, "unknown nesting %q", d.Nesting)
+	}
+	if d.Elem == nil {
+		return
+	}
+	switch d.Elem.Type {
+	case "SchemaElements":
+		if d.Elem.Elements != nil {
+			checkDefinition(s, path+".Elem", *d.Elem.Elements, report)
+		} else if !valueTypes[d.Elem.ElementsType] {
+			report(path+".Elem", "unknown elements type %q", d.Elem.ElementsType)
+		}
+	case "SchemaInfo":
+		checkInfo(s, path, d.Elem.Info, report)
+	case ElementTypeBackRef:
+		if _, err := ResolveBackRef(s, d.Elem.Ref); err != nil {
+			report(path+".Elem", "%s", err)
+		}
+	case "":
+		if !valueTypes[d.Elem.Value] {
+			report(path+".Elem", "unknown value type %q", d.Elem.Value)
+		}
+	}
+}
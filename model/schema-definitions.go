@@ -0,0 +1,191 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package model
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// ElementTypeRef is the SchemaElement.Type of a reference to a nested
+// schema in the definitions table.
+const ElementTypeRef = "SchemaRef"
+
+// deduplicate returns a copy of info whose nested schemas are replaced
+// by references to the definitions table. Nested schemas that are equal,
+// such as those of a *schema.Resource shared by several attributes, are
+// defined once. info is not modified.
+func (e *Encoder) deduplicate(info SchemaInfo) SchemaInfo {
+	if info == nil {
+		return nil
+	}
+	result := make(SchemaInfo, len(info))
+	for name, d := range info {
+		result[name] = e.deduplicateDefinition(d)
+	}
+	return result
+}
+
+func (e *Encoder) deduplicateDefinition(d SchemaDefinition) SchemaDefinition {
+	if d.Elem == nil {
+		return d
+	}
+	elem := *d.Elem
+	if elem.Type == "SchemaInfo" {
+		elem = SchemaElement{Type: ElementTypeRef, Ref: e.define(elem.Info)}
+	} else if elem.Elements != nil {
+		elements := e.deduplicateDefinition(*elem.Elements)
+		elem.Elements = &elements
+	}
+	d.Elem = &elem
+	return d
+}
+
+// define adds info to the definitions table and returns its ID, which
+// is derived from its content so that it is stable across providers and
+// their versions.
+func (e *Encoder) define(info SchemaInfo) string {
+	data, err := json.Marshal(e.deduplicate(info))
+	if err != nil {
+		e.err = err
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	id := hex.EncodeToString(sum[:8])
+	if existing, ok := e.definitions[id]; ok && !bytes.Equal(existing, data) {
+		e.err = fmt.Errorf("definitions with ID %s differ", id)
+	}
+	e.definitions[id] = data
+	return id
+}
+
+// writeDefinitions writes the definitions table, which is omitted if it
+// is empty.
+func (e *Encoder) writeDefinitions() {
+	if len(e.definitions) == 0 {
+		return
+	}
+	ids := make([]string, 0, len(e.definitions))
+	for id := range e.definitions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	e.write(",\n" + indent)
+	e.key("definitions")
+	e.write("{")
+	for i, id := range ids {
+		if i > 0 {
+			e.write(",")
+		}
+		e.write("\n" + indent + indent)
+		e.key(id)
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, e.definitions[id], indent+indent, indent); err != nil && e.err == nil {
+			e.err = err
+		}
+		e.write(buf.String())
+	}
+	e.write("\n" + indent + "}")
+}
+
+// expandDefinitions replaces the references of a loaded schema by the
+// nested schemas they refer to, and empties its definitions table.
+// Nested schemas referenced more than once are shared, not copied.
+func expandDefinitions(s *ResourceProviderSchema) error {
+	x := &expander{
+		definitions: s.Definitions,
+		expanded:    make(map[string]SchemaInfo),
+		expanding:   make(map[string]bool),
+	}
+	x.info(s.Provider)
+	if s.ProviderHeader.ProviderMeta != nil {
+		x.definition(s.ProviderHeader.ProviderMeta)
+	}
+	for _, info := range s.Resources {
+		x.info(SchemaInfo(info))
+	}
+	for _, info := range s.DataSources {
+		x.info(SchemaInfo(info))
+	}
+	if x.err != nil {
+		return x.err
+	}
+	s.Definitions = nil
+	if s.SchemaVersion == SchemaVersionDefinitions {
+		s.SchemaVersion = SchemaVersionInline
+	}
+	return nil
+}
+
+type expander struct {
+	definitions map[string]SchemaInfo
+	expanded    map[string]SchemaInfo
+	// Definitions being expanded, to detect circular references
+	expanding map[string]bool
+	err       error
+}
+
+func (x *expander) info(info SchemaInfo) {
+	for name, d := range info {
+		x.definition(&d)
+		info[name] = d
+	}
+}
+
+func (x *expander) definition(d *SchemaDefinition) {
+	if d.Elem == nil {
+		return
+	}
+	if d.Elem.Elements != nil {
+		x.definition(d.Elem.Elements)
+	}
+	if d.Elem.Type == ElementTypeRef {
+		d.Elem = &SchemaElement{Type: "SchemaInfo", Info: x.resolve(d.Elem.Ref)}
+	} else {
+		x.info(d.Elem.Info)
+	}
+}
+
+func (x *expander) resolve(id string) SchemaInfo {
+	if info, ok := x.expanded[id]; ok {
+		return info
+	}
+	info, ok := x.definitions[id]
+	if !ok {
+		x.fail(fmt.Errorf("unknown definition %q", id))
+		return nil
+	}
+	if x.expanding[id] {
+		x.fail(fmt.Errorf("definition %q refers to itself", id))
+		return nil
+	}
+	x.expanding[id] = true
+	x.info(info)
+	delete(x.expanding, id)
+	x.expanded[id] = info
+	return info
+}
+
+func (x *expander) fail(err error) {
+	if x.err == nil {
+		x.err = err
+	}
+}
@@ -0,0 +1,114 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDefinitionsRoundTrip(t *testing.T) {
+	pi := &ProviderInfo{Name: "test", Revision: "1.0.0"}
+	s, err := new(Exporter).Export(testProvider(5, nil), pi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written bytes.Buffer
+	if err = Write(s, &written); err != nil {
+		t.Fatal(err)
+	}
+
+	// The tags block shared by all resources is defined once, as are the
+	// provider_meta block and the timeouts block of each test_r resource,
+	// whose defaults differ
+	var encoded struct {
+		SchemaVersion string                            `json:".schema_version"`
+		Resources     map[string]SchemaInfoWithTimeouts `json:"resources"`
+		Definitions   map[string]SchemaInfo             `json:"definitions"`
+	}
+	if err = json.Unmarshal(written.Bytes(), &encoded); err != nil {
+		t.Fatal(err)
+	}
+	if encoded.SchemaVersion != SchemaVersionDefinitions {
+		t.Errorf(".schema_version = %q, want %q", encoded.SchemaVersion, SchemaVersionDefinitions)
+	}
+	if len(encoded.Definitions) != 2+5 {
+		t.Errorf("%d definitions, want %d", len(encoded.Definitions), 2+5)
+	}
+	ref := encoded.Resources["test_r000"]["tags"].Elem
+	if ref == nil || ref.Type != ElementTypeRef || encoded.Definitions[ref.Ref] == nil {
+		t.Fatalf("tags of test_r000 = %+v, want a reference to a definition", ref)
+	}
+	for name, info := range encoded.Resources {
+		if elem := info["tags"].Elem; name != "test_tree" && !reflect.DeepEqual(elem, ref) {
+			t.Errorf("tags of %s = %+v, want %+v", name, elem, ref)
+		}
+	}
+
+	loaded, err := Load(bytes.NewReader(written.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.SchemaVersion != SchemaVersionInline || loaded.Definitions != nil {
+		t.Errorf("Load() = schema version %q with %d definitions, want %q without", loaded.SchemaVersion, len(loaded.Definitions), SchemaVersionInline)
+	}
+	first, second := loaded.Resources["test_r000"]["tags"].Elem, loaded.Resources["test_r001"]["tags"].Elem
+	if first.Type != "SchemaInfo" || reflect.ValueOf(first.Info).Pointer() != reflect.ValueOf(second.Info).Pointer() {
+		t.Errorf("Load() did not share the tags block: %+v, %+v", first, second)
+	}
+
+	// Loading restores the exported schema
+	var want, got bytes.Buffer
+	if err = WriteInline(s, &want); err != nil {
+		t.Fatal(err)
+	}
+	if err = WriteInline(loaded, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != want.String() {
+		t.Errorf("WriteInline(Load()) =\n%s\nwant\n%s", got.String(), want.String())
+	}
+}
+
+func TestLoadDefinitionErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		definitions string
+		want        string
+	}{
+		{"unknown", `{}`, `unknown definition "a"`},
+		{"circular", `{"a": {"x": {"Type": "List", "Elem": {"Type": "SchemaRef", "Ref": "a"}}}}`, `definition "a" refers to itself`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc := `{
+  "name": "test",
+  ".schema_version": "3",
+  "provider": {},
+  "resources": {"test_thing": {"x": {"Type": "List", "Elem": {"Type": "SchemaRef", "Ref": "a"}}}},
+  "data-sources": {},
+  "definitions": ` + test.definitions + `
+}`
+			_, err := Load(strings.NewReader(doc))
+			if err == nil || err.Error() != test.want {
+				t.Errorf("Load() error = %v, want %s", err, test.want)
+			}
+		})
+	}
+}
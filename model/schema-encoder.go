@@ -0,0 +1,242 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package model
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+const indent = "  "
+
+// Encoder writes a schema in the format of Write one resource or data
+// source at a time, so that a provider never has to be held in memory
+// as a whole. Resources must be encoded before data sources, each in
+// ascending order of their names, and Close must be called at the end.
+// The output is byte for byte that of Write, or of WriteInline if Inline
+// is set; like them, the encoder canonicalizes what it is given in place.
+type Encoder struct {
+	// Write nested schemas inline where they are used, in the shape of
+	// SchemaVersionInline, instead of to the definitions table. Must be
+	// set before anything is encoded.
+	Inline bool
+
+	w    *bufio.Writer
+	err  error
+	head *ResourceProviderSchema
+
+	// The section being written: "resources" or "data-sources"
+	section string
+	// Entries written to the section so far
+	entries int
+	last    string
+
+	resourceHeaders   map[string]ResourceHeader
+	dataSourceHeaders map[string]ResourceHeader
+	// Compact JSON of the nested schemas by ID
+	definitions map[string][]byte
+}
+
+// NewEncoder returns an Encoder writing s to w. The resources, data
+// sources, headers and definitions of s are ignored, and everything
+// else is written before the first resource.
+func NewEncoder(w io.Writer, s *ResourceProviderSchema) *Encoder {
+	head := *s
+	head.Resources, head.DataSources = nil, nil
+	head.ResourceHeaders, head.DataSourceHeaders = nil, nil
+	head.Definitions = nil
+	return &Encoder{
+		w:                 bufio.NewWriter(w),
+		head:              &head,
+		resourceHeaders:   make(map[string]ResourceHeader),
+		dataSourceHeaders: make(map[string]ResourceHeader),
+		definitions:       make(map[string][]byte),
+	}
+}
+
+// writeHead writes everything that precedes the resources.
+func (e *Encoder) writeHead() {
+	head := e.head
+	Canonicalize(head)
+	if !e.Inline {
+		head.Provider = e.deduplicate(head.Provider)
+		if head.ProviderHeader.ProviderMeta != nil {
+			providerMeta := e.deduplicateDefinition(*head.ProviderHeader.ProviderMeta)
+			head.ProviderHeader.ProviderMeta = &providerMeta
+		}
+		if head.SchemaVersion == SchemaVersionInline {
+			head.SchemaVersion = SchemaVersionDefinitions
+		}
+	}
+
+	e.write("{")
+	for _, field := range []struct {
+		key   string
+		value interface{}
+	}{
+		{"name", head.Name},
+		{"type", head.Type},
+		{"version", head.Version},
+		{".sdk_type", head.SDKType},
+		{".schema_version", head.SchemaVersion},
+		{"provider", head.Provider},
+		{"provider-header", head.ProviderHeader},
+	} {
+		e.write("\n" + indent)
+		e.key(field.key)
+		e.value(field.value, indent)
+		e.write(",")
+	}
+	e.head = nil
+}
+
+// Resource writes the schema of the resource called name and records
+// its header, if any.
+func (e *Encoder) Resource(name string, info SchemaInfoWithTimeouts, header *ResourceHeader) error {
+	return e.entry("resources", name, info, header, e.resourceHeaders)
+}
+
+// DataSource writes the schema of the data source called name and
+// records its header, if any.
+func (e *Encoder) DataSource(name string, info SchemaInfoWithTimeouts, header *ResourceHeader) error {
+	return e.entry("data-sources", name, info, header, e.dataSourceHeaders)
+}
+
+func (e *Encoder) entry(section, name string, info SchemaInfoWithTimeouts, header *ResourceHeader, headers map[string]ResourceHeader) error {
+	if e.err != nil {
+		return e.err
+	}
+	if section == "resources" && e.section == "data-sources" || e.section == "closed" {
+		return fmt.Errorf("resource %q encoded after data sources", name)
+	}
+	if e.section == section && name <= e.last {
+		return fmt.Errorf("%s %q encoded out of order, after %q", section, name, e.last)
+	}
+	for e.section != section {
+		e.nextSection()
+	}
+
+	canonicalInfo(SchemaInfo(info))
+	if !e.Inline {
+		info = SchemaInfoWithTimeouts(e.deduplicate(SchemaInfo(info)))
+	}
+	if e.entries > 0 {
+		e.write(",")
+	}
+	e.write("\n" + indent + indent)
+	e.key(name)
+	e.value(info, indent+indent)
+	e.entries++
+	e.last = name
+
+	if header != nil {
+		h := *header
+		sort.Ints(h.StateUpgraders)
+		headers[name] = h
+	}
+	return e.err
+}
+
+// nextSection closes the section being written and opens the next one.
+func (e *Encoder) nextSection() {
+	if e.section != "" {
+		if e.entries > 0 {
+			e.write("\n" + indent)
+		}
+		e.write("},")
+	}
+	switch e.section {
+	case "":
+		e.writeHead()
+		e.section = "resources"
+	case "resources":
+		e.section = "data-sources"
+	default:
+		e.section = "closed"
+		return
+	}
+	e.write("\n" + indent)
+	e.key(e.section)
+	e.write("{")
+	e.entries, e.last = 0, ""
+}
+
+// Close writes the headers and the end of the schema, and flushes the
+// output.
+func (e *Encoder) Close() error {
+	if e.err != nil {
+		return e.err
+	}
+	if e.section == "closed" {
+		return fmt.Errorf("encoder already closed")
+	}
+	for e.section != "data-sources" {
+		e.nextSection()
+	}
+	if e.entries > 0 {
+		e.write("\n" + indent)
+	}
+	e.write("}")
+	e.section = "closed"
+
+	// Empty header maps are omitted
+	for _, headers := range []struct {
+		key     string
+		headers map[string]ResourceHeader
+	}{
+		{"resource-headers", e.resourceHeaders},
+		{"data-source-headers", e.dataSourceHeaders},
+	} {
+		if len(headers.headers) > 0 {
+			e.write(",\n" + indent)
+			e.key(headers.key)
+			e.value(headers.headers, indent)
+		}
+	}
+	e.writeDefinitions()
+	e.write("\n}")
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+func (e *Encoder) write(s string) {
+	if e.err == nil {
+		_, e.err = e.w.WriteString(s)
+	}
+}
+
+func (e *Encoder) key(key string) {
+	e.value(key, "")
+	e.write(": ")
+}
+
+// value writes v as encoding/json would at the given indentation.
+func (e *Encoder) value(v interface{}, prefix string) {
+	if e.err != nil {
+		return
+	}
+	data, err := json.MarshalIndent(v, prefix, indent)
+	if err != nil {
+		e.err = err
+		return
+	}
+	_, e.err = e.w.Write(data)
+}
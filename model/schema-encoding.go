@@ -0,0 +1,160 @@
+/*
+   Copyright 2000-2017 JetBrains s.r.o.
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Load reads a schema in the format written by Write or WriteInline.
+// References to the definitions table are resolved, so nested schemas
+// are inline in the result. Numbers in defaults and allowed values are
+// kept as json.Number, so writing the result again reproduces the input
+// if Write produced it.
+func Load(r io.Reader) (*ResourceProviderSchema, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	result := new(ResourceProviderSchema)
+	if err := decoder.Decode(result); err != nil {
+		return nil, err
+	}
+	if err := expandDefinitions(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Write encodes the schema as indented JSON in its canonical form,
+// canonicalizing s in place, see Canonicalize. Nested schemas are
+// written once to the definitions table. Schemas too large to be held
+// in memory can be written one resource at a time by an Encoder.
+func Write(s *ResourceProviderSchema, w io.Writer) error {
+	return write(s, w, false)
+}
+
+// WriteInline is like Write, but writes nested schemas inline wherever
+// they are used, as SchemaVersionInline did.
+func WriteInline(s *ResourceProviderSchema, w io.Writer) error {
+	return write(s, w, true)
+}
+
+func write(s *ResourceProviderSchema, w io.Writer, inline bool) error {
+	Canonicalize(s)
+	e := NewEncoder(w, s)
+	e.Inline = inline
+	for _, name := range sortedNames(s.Resources) {
+		if err := e.Resource(name, s.Resources[name], nil); err != nil {
+			return err
+		}
+	}
+	for _, name := range sortedNames(s.DataSources) {
+		if err := e.DataSource(name, s.DataSources[name], nil); err != nil {
+			return err
+		}
+	}
+	// Headers are written as given, even those without a schema
+	e.resourceHeaders, e.dataSourceHeaders = s.ResourceHeaders, s.DataSourceHeaders
+	return e.Close()
+}
+
+// WriteFile writes the schema to outputFilePath, replacing any existing
+// file.
+func WriteFile(s *ResourceProviderSchema, outputFilePath string) error {
+	return EncodeFile(outputFilePath, func(w io.Writer) error {
+		return Write(s, w)
+	})
+}
+
+// EncodeFile lets encode write the schema to outputFilePath, replacing
+// any existing file only if encode succeeds, see OutputFile.
+func EncodeFile(outputFilePath string, encode func(w io.Writer) error) error {
+	file, err := CreateOutputFile(outputFilePath)
+	if err != nil {
+		return err
+	}
+	if err = encode(file); err != nil {
+		file.Discard()
+		return err
+	}
+	return file.Close()
+}
+
+// OutputFile is a file written under a temporary name in the directory
+// of its destination. Close renames it into place and Discard removes
+// it, so that a failed write leaves an existing file as it was.
+type OutputFile struct {
+	file *os.File
+	path string
+	mode os.FileMode
+}
+
+// CreateOutputFile starts writing the file at path. The permissions of
+// a file being replaced are kept.
+func CreateOutputFile(path string) (*OutputFile, error) {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	file, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &OutputFile{file: file, path: path, mode: mode}, nil
+}
+
+func (f *OutputFile) Write(p []byte) (int, error) {
+	return f.file.Write(p)
+}
+
+// Close replaces the destination with what was written, or discards it
+// if that fails.
+func (f *OutputFile) Close() error {
+	err := f.file.Chmod(f.mode)
+	if err == nil {
+		err = f.file.Sync()
+	}
+	if err == nil {
+		err = f.file.Close()
+	}
+	if err == nil {
+		err = os.Rename(f.file.Name(), f.path)
+	}
+	if err != nil {
+		f.Discard()
+	}
+	return err
+}
+
+// Discard removes what was written, leaving the destination as it was.
+func (f *OutputFile) Discard() {
+	f.file.Close()
+	os.Remove(f.file.Name())
+}
+
+func sortedNames(resources map[string]SchemaInfoWithTimeouts) []string {
+	names := make([]string, 0, len(resources))
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
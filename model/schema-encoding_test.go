@@ -0,0 +1,98 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package model
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncodeFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "encode-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.json")
+	if err = ioutil.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// ioutil.WriteFile is subject to the umask
+	if err = os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	encodeErr := errors.New("encode failed")
+	err = EncodeFile(path, func(w io.Writer) error {
+		io.WriteString(w, "partial")
+		return encodeErr
+	})
+	if err != encodeErr {
+		t.Errorf("EncodeFile() = %v, want %v", err, encodeErr)
+	}
+	assertFile(t, path, "old", 0600)
+
+	if err = EncodeFile(path, func(w io.Writer) error {
+		_, err := io.WriteString(w, "new")
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, path, "new", 0600)
+
+	created := filepath.Join(dir, "created.json")
+	if err = EncodeFile(created, func(w io.Writer) error {
+		_, err := io.WriteString(w, "new")
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, created, "new", 0644)
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		var names []string
+		for _, f := range files {
+			names = append(names, f.Name())
+		}
+		t.Errorf("files left = %q, want only test.json and created.json", names)
+	}
+}
+
+func assertFile(t *testing.T, path, content string, mode os.FileMode) {
+	t.Helper()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != content {
+		t.Errorf("%s = %q, want %q", filepath.Base(path), data, content)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != mode {
+		t.Errorf("%s mode = %v, want %v", filepath.Base(path), info.Mode().Perm(), mode)
+	}
+}
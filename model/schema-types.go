@@ -0,0 +1,326 @@
+/*
+   Copyright 2000-2017 JetBrains s.r.o.
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package model
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/evan-cleary/tf-schema-extractor/validators"
+)
+
+type SchemaElement struct {
+	// One of "schema.ValueType" or "SchemaElements" or "SchemaInfo" or
+	// ElementTypeBackRef, or ElementTypeRef when encoded
+	Type string `json:",omitempty"`
+	// Set for simple types (from ValueType)
+	Value string `json:",omitempty"`
+	// Set if Type == "SchemaElements"
+	ElementsType string `json:",omitempty"`
+	// Set if Type == "SchemaElements": the element's own definition,
+	// including its nested Elem, constraints and flags
+	Elements *SchemaDefinition `json:",omitempty"`
+	// Set if Type == "SchemaInfo"
+	Info SchemaInfo `json:",omitempty"`
+	// Set if Type == ElementTypeRef: the ID of the nested schema in the
+	// definitions table. Set if Type == ElementTypeBackRef: the path of
+	// the schema further up that it repeats, see ResolveBackRef
+	Ref string `json:",omitempty"`
+}
+
+type SchemaDefinition struct {
+	Type               string `json:",omitempty"`
+	Optional           bool   `json:",omitempty"`
+	Required           bool   `json:",omitempty"`
+	Description        string `json:",omitempty"`
+	DescriptionKind    string `json:",omitempty"`
+	InputDefault       string `json:",omitempty"`
+	Computed           bool   `json:",omitempty"`
+	ForceNew           bool   `json:",omitempty"`
+	Sensitive          bool   `json:",omitempty"`
+	MaxItems           int    `json:",omitempty"`
+	MinItems           int    `json:",omitempty"`
+	PromoteSingle      bool   `json:",omitempty"`
+	IsBlock            bool   `json:",omitempty"`
+	ConfigImplicitMode string `json:",omitempty"`
+	// NestingSingle for a nested attribute or block that holds a single
+	// object, which is also exported as a List with MaxItems 1.
+	// NestingMap for a nested attribute that holds a map of objects,
+	// which is exported as a Map like an attribute of object type.
+	Nesting string `json:",omitempty"`
+
+	ComputedWhen  []string `json:",omitempty"`
+	ConflictsWith []string `json:",omitempty"`
+	ExactlyOneOf  []string `json:",omitempty"`
+	AtLeastOneOf  []string `json:",omitempty"`
+	RequiredWith  []string `json:",omitempty"`
+
+	Deprecated string `json:",omitempty"`
+	Removed    string `json:",omitempty"`
+
+	// Go type of a terraform-plugin-framework custom attribute type
+	CustomType string `json:",omitempty"`
+
+	// Inferred from ValidateFunc or ValidateDiagFunc
+	Validation *validators.Constraints `json:",omitempty"`
+
+	Default *SchemaDefault `json:",omitempty"`
+	Elem    *SchemaElement `json:",omitempty"`
+}
+
+type SchemaDefault struct {
+	// One of DefaultSourceStatic or DefaultSourceFunc
+	Source string
+	// Native JSON value: bool, number, string, list or map. Only set
+	// for DefaultSourceStatic; DefaultFunc values are not recorded.
+	Value interface{} `json:",omitempty"`
+}
+
+type SchemaInfo map[string]SchemaDefinition
+
+// SchemaInfoWithTimeouts is the schema of a resource or data source,
+// including its "timeouts" block.
+type SchemaInfoWithTimeouts map[string]SchemaDefinition
+
+// ResourceProviderSchema is the exported schema of a provider, its
+// resources and its data sources.
+type ResourceProviderSchema struct {
+	Name              string                            `json:"name"`
+	Type              string                            `json:"type"`
+	Version           string                            `json:"version"`
+	SDKType           string                            `json:".sdk_type"`
+	SchemaVersion     string                            `json:".schema_version"`
+	Provider          SchemaInfo                        `json:"provider"`
+	ProviderHeader    ProviderHeader                    `json:"provider-header"`
+	Resources         map[string]SchemaInfoWithTimeouts `json:"resources"`
+	DataSources       map[string]SchemaInfoWithTimeouts `json:"data-sources"`
+	ResourceHeaders   map[string]ResourceHeader         `json:"resource-headers,omitempty"`
+	DataSourceHeaders map[string]ResourceHeader         `json:"data-source-headers,omitempty"`
+	// Nested schemas by ID, only used in encoded form: Load resolves the
+	// references to them and the Encoder fills the table
+	Definitions map[string]SchemaInfo `json:"definitions,omitempty"`
+}
+
+// ProviderHeader holds the provider-level metadata that is not part of
+// the provider's configuration schema.
+type ProviderHeader struct {
+	// Terraform version the provider was configured for, if any
+	TerraformVersion string `json:",omitempty"`
+	// Set if the provider has a configure function
+	Configurable bool `json:",omitempty"`
+	// The provider_meta block accepted in a module's terraform block
+	ProviderMeta *SchemaDefinition `json:",omitempty"`
+}
+
+// ResourceHeader holds the resource-level metadata that is not part of
+// the resource's attribute schema.
+type ResourceHeader struct {
+	Description        string `json:",omitempty"`
+	DescriptionKind    string `json:",omitempty"`
+	DeprecationMessage string `json:",omitempty"`
+	SchemaVersion      int
+	// Schema versions that have a state upgrader to the next version
+	StateUpgraders []int `json:",omitempty"`
+	Importable     bool  `json:",omitempty"`
+	CustomizeDiff  bool  `json:",omitempty"`
+	// Implemented operations, a subset of the Operation* constants
+	Operations []string `json:",omitempty"`
+	// Set if none of create, update or delete is implemented
+	ReadOnly bool `json:",omitempty"`
+}
+
+type ProviderInfo struct {
+	Name     string
+	Revision string
+}
+
+// Values of ResourceProviderSchema.SDKType. Schemas exported from
+// Terraform's own helper/schema package leave it empty.
+const (
+	SDKTypeSDK       = "terraform-sdk"
+	SDKTypeSDK2      = "terraform-sdk-2"
+	SDKTypeFramework = "terraform-plugin-framework"
+	// Read from a provider binary, which does not reveal its SDK
+	SDKTypePlugin = "plugin-protocol"
+	// Imported from "terraform providers schema -json", which does not
+	// reveal it either
+	SDKTypeProvidersSchema = "providers-schema-json"
+)
+
+// Values of ResourceProviderSchema.SchemaVersion.
+const (
+	// Nested schemas are written inline wherever they are used. Schemas
+	// in memory always have this shape.
+	SchemaVersionInline = "2"
+	// Nested schemas are written once to the definitions table and
+	// referenced by ID
+	SchemaVersionDefinitions = "3"
+)
+
+// Values of SchemaDefinition.Nesting.
+const (
+	NestingSingle = "Single"
+	NestingMap    = "Map"
+)
+
+const (
+	DefaultSourceStatic = "Default"
+	DefaultSourceFunc   = "DefaultFunc"
+)
+
+const (
+	OperationCreate = "create"
+	OperationRead   = "read"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+)
+
+const TimeoutsConfigKey = "timeouts"
+
+const (
+	TimeoutCreate  = "create"
+	TimeoutRead    = "read"
+	TimeoutUpdate  = "update"
+	TimeoutDelete  = "delete"
+	TimeoutDefault = "default"
+)
+
+// NewResourceProviderSchema returns an empty schema for the provider
+// described by pi, exported through the given SDK.
+func NewResourceProviderSchema(pi *ProviderInfo, sdkType string) *ResourceProviderSchema {
+	result := new(ResourceProviderSchema)
+
+	result.SchemaVersion = SchemaVersionInline
+	result.SDKType = sdkType
+	result.Name = pi.Name
+	result.Type = "provider"
+	result.Version = pi.Revision
+	result.Provider = make(SchemaInfo)
+	result.Resources = make(map[string]SchemaInfoWithTimeouts)
+	result.DataSources = make(map[string]SchemaInfoWithTimeouts)
+	result.ResourceHeaders = make(map[string]ResourceHeader)
+	result.DataSourceHeaders = make(map[string]ResourceHeader)
+	return result
+}
+
+// TimeoutKeys lists the operations a resource can configure a timeout for.
+func TimeoutKeys() []string {
+	return []string{
+		TimeoutCreate,
+		TimeoutRead,
+		TimeoutUpdate,
+		TimeoutDelete,
+		TimeoutDefault,
+	}
+}
+
+// NewResourceHeader fills the operation-derived fields of a header.
+func NewResourceHeader(create, read, update, delete bool) ResourceHeader {
+	header := ResourceHeader{ReadOnly: !create && !update && !delete}
+	for _, op := range []struct {
+		name        string
+		implemented bool
+	}{
+		{OperationCreate, create},
+		{OperationRead, read},
+		{OperationUpdate, update},
+		{OperationDelete, delete},
+	} {
+		if op.implemented {
+			header.Operations = append(header.Operations, op.name)
+		}
+	}
+	return header
+}
+
+// SingleBlock describes an optional block that appears at most once
+// and holds the given attributes, such as "timeouts".
+func SingleBlock(info SchemaInfo) SchemaDefinition {
+	return SchemaDefinition{
+		Type:               "List",
+		Optional:           true,
+		MaxItems:           1,
+		IsBlock:            true,
+		ConfigImplicitMode: "Block",
+		Elem:               &SchemaElement{Type: "SchemaInfo", Info: info},
+	}
+}
+
+// TimeoutAttribute describes a timeout attribute of the "timeouts" block
+// defaulting to d.
+func TimeoutAttribute(d time.Duration) SchemaDefinition {
+	return SchemaDefinition{
+		Type:     "String",
+		Optional: true,
+		Default:  &SchemaDefault{Source: DefaultSourceStatic, Value: d.String()},
+	}
+}
+
+// ShortenType strips the "Type" prefix from an SDK ValueType name.
+func ShortenType(value string) string {
+	if len(value) > 4 && value[0:4] == "Type" {
+		return value[4:]
+	}
+	return value
+}
+
+// JSONValue converts a default value into a form encoding/json renders
+// natively. Values without a JSON representation fall back to their
+// string form.
+func JSONValue(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint()
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Sprintf("%v", f)
+		}
+		return f
+	case reflect.String:
+		return rv.String()
+	case reflect.Slice, reflect.Array:
+		list := make([]interface{}, rv.Len())
+		for i := range list {
+			list[i] = JSONValue(rv.Index(i).Interface())
+		}
+		return list
+	case reflect.Map:
+		m := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			m[fmt.Sprintf("%v", iter.Key().Interface())] = JSONValue(iter.Value().Interface())
+		}
+		return m
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		return JSONValue(rv.Elem().Interface())
+	}
+	return fmt.Sprintf("%v", value)
+}
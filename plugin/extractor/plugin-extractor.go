@@ -0,0 +1,144 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package extractor
+
+import (
+	"github.com/evan-cleary/tf-schema-extractor/coreschema"
+	"github.com/evan-cleary/tf-schema-extractor/model"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// PluginExtractor extracts the schema of a compiled provider binary by
+// starting it the way Terraform does and asking it over the plugin
+// protocol, version 5 or 6.
+type PluginExtractor struct{}
+
+// handshake is the go-plugin handshake of Terraform providers.
+var handshake = plugin.HandshakeConfig{
+	ProtocolVersion:  5,
+	MagicCookieKey:   "TF_PLUGIN_MAGIC_COOKIE",
+	MagicCookieValue: "d602bf8f470bc67ca7faa0386276bbdd4330efaf76d1a219cb4d6991ca9872b2",
+}
+
+// getSchemaMethods are the GetProviderSchema RPCs by protocol version.
+var getSchemaMethods = map[int]string{
+	5: "/tfplugin5.Provider/GetSchema",
+	6: "/tfplugin6.Provider/GetProviderSchema",
+}
+
+// grpcPlugin hands out the raw client connection to the provider.
+type grpcPlugin struct {
+	plugin.Plugin
+}
+
+func (grpcPlugin) GRPCServer(*plugin.GRPCBroker, *grpc.Server) error {
+	return errors.New("providers are not served by the extractor")
+}
+
+func (grpcPlugin) GRPCClient(_ context.Context, _ *plugin.GRPCBroker, conn *grpc.ClientConn) (interface{}, error) {
+	return conn, nil
+}
+
+// InfoFromPath derives the provider name and version from a binary
+// named like "terraform-provider-aws_v3.30.0_x5".
+func InfoFromPath(path string) *ProviderInfo {
+	name := strings.TrimSuffix(filepath.Base(path), ".exe")
+	name = strings.TrimPrefix(name, "terraform-provider-")
+	pi := &ProviderInfo{Name: name}
+	if i := strings.Index(name, "_v"); i >= 0 {
+		pi.Name = name[:i]
+		pi.Revision = strings.SplitN(name[i+2:], "_", 2)[0]
+	}
+	return pi
+}
+
+// Export starts the provider binary at path and exports its schema.
+// Anything the model cannot represent is returned as problems, see
+// coreschema.ToModel.
+func (m *PluginExtractor) Export(path string, pi *ProviderInfo) (*ResourceProviderSchema, []string, error) {
+	ps, err := m.GetProviderSchema(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, problems := coreschema.ToModel(ps, pi, model.SDKTypePlugin)
+	return result, problems, nil
+}
+
+// GetProviderSchema starts the provider binary at path and returns the
+// schema it reports, in Terraform's core schema form.
+func (m *PluginExtractor) GetProviderSchema(path string) (*coreschema.ProviderSchema, error) {
+	versionedPlugins := make(map[int]plugin.PluginSet)
+	for version := range getSchemaMethods {
+		versionedPlugins[version] = plugin.PluginSet{"provider": grpcPlugin{}}
+	}
+	client := plugin.NewClient(&plugin.ClientConfig{
+		Cmd:              exec.Command(path),
+		HandshakeConfig:  handshake,
+		VersionedPlugins: versionedPlugins,
+		AllowedProtocols: []plugin.Protocol{plugin.ProtocolGRPC},
+		AutoMTLS:         true,
+		Logger:           hclog.NewNullLogger(),
+	})
+	defer client.Kill()
+
+	rpcClient, err := client.Client()
+	if err != nil {
+		return nil, fmt.Errorf("starting %s: %s", path, err)
+	}
+	raw, err := rpcClient.Dispense("provider")
+	if err != nil {
+		return nil, fmt.Errorf("starting %s: %s", path, err)
+	}
+	conn := raw.(*grpc.ClientConn)
+
+	var request, response []byte
+	method := getSchemaMethods[client.NegotiatedVersion()]
+	if err = conn.Invoke(context.Background(), method, &request, &response, grpc.ForceCodec(rawCodec{})); err != nil {
+		return nil, fmt.Errorf("%s: %s", method, err)
+	}
+	return decodeProviderSchema(response)
+}
+
+func (m *PluginExtractor) Generate(path string, pi *ProviderInfo, outputPath string) {
+	outputFilePath := filepath.Join(outputPath, fmt.Sprintf("%s.json", pi.Name))
+
+	problems, err := m.DoGenerate(path, pi, outputFilePath)
+	for _, problem := range problems {
+		fmt.Fprintln(os.Stderr, "Warning: ", problem)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: ", err.Error())
+		os.Exit(255)
+	}
+}
+
+func (m *PluginExtractor) DoGenerate(path string, pi *ProviderInfo, outputFilePath string) ([]string, error) {
+	result, problems, err := m.Export(path, pi)
+	if err != nil {
+		return nil, err
+	}
+	return problems, model.WriteFile(result, outputFilePath)
+}
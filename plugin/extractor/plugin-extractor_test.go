@@ -0,0 +1,286 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package extractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/evan-cleary/tf-schema-extractor/coreschema"
+	"github.com/evan-cleary/tf-schema-extractor/model"
+)
+
+// stubs are the paths of the stub provider built by TestMain, by
+// protocol version.
+var stubs = make(map[int]string)
+
+func TestMain(m *testing.M) {
+	os.Exit(runTests(m))
+}
+
+func runTests(m *testing.M) int {
+	dir, err := ioutil.TempDir("", "plugin-extractor")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer os.RemoveAll(dir)
+	for _, version := range []int{5, 6} {
+		path := filepath.Join(dir, fmt.Sprintf("terraform-provider-stub_v1.2.3_x%d", version))
+		cmd := exec.Command("go", "build", "-o", path, "-ldflags", fmt.Sprintf("-X main.protocol=%d", version), "./testdata/stub-provider")
+		cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+		if err := cmd.Run(); err != nil {
+			fmt.Fprintf(os.Stderr, "building the protocol %d stub provider: %s\n", version, err)
+			return 1
+		}
+		stubs[version] = path
+	}
+	return m.Run()
+}
+
+func TestGetProviderSchema(t *testing.T) {
+	for _, version := range []int{5, 6} {
+		t.Run(fmt.Sprintf("protocol %d", version), func(t *testing.T) {
+			got, err := new(PluginExtractor).GetProviderSchema(stubs[version])
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := stubSchema(version)
+			if g, w := encode(t, got), encode(t, want); g != w {
+				t.Errorf("GetProviderSchema() =\n%s\nwant\n%s", g, w)
+			}
+			if g, w := encode(t, got.ProviderMeta), encode(t, want.ProviderMeta); g != w {
+				t.Errorf("GetProviderSchema().ProviderMeta =\n%s\nwant\n%s", g, w)
+			}
+		})
+	}
+}
+
+func TestExport(t *testing.T) {
+	for _, version := range []int{5, 6} {
+		t.Run(fmt.Sprintf("protocol %d", version), func(t *testing.T) {
+			path := stubs[version]
+			s, problems, err := new(PluginExtractor).Export(path, InfoFromPath(path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(problems) != 0 {
+				t.Errorf("Export() problems = %q, want none", problems)
+			}
+			if s.Name != "stub" || s.Version != "1.2.3" || s.SDKType != model.SDKTypePlugin {
+				t.Errorf("Export() = %s %s (%s), want stub 1.2.3 (%s)", s.Name, s.Version, s.SDKType, model.SDKTypePlugin)
+			}
+			if h := s.ResourceHeaders["stub_thing"]; h.Description != "A *thing*" || h.DescriptionKind != "markdown" || h.SchemaVersion != 2 {
+				t.Errorf("Export() header of stub_thing = %+v", h)
+			}
+
+			want := thingDefinitions(version)
+			thing := s.Resources["stub_thing"]
+			if len(thing) != len(want) {
+				t.Errorf("Export() stub_thing has %d attributes, want %d", len(thing), len(want))
+			}
+			for name, w := range want {
+				if g := thing[name]; !reflect.DeepEqual(g, w) {
+					t.Errorf("Export() stub_thing.%s =\n%s\nwant\n%s", name, encode(t, g), encode(t, w))
+				}
+			}
+		})
+	}
+}
+
+func encode(t *testing.T, v interface{}) string {
+	t.Helper()
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// stubSchema is the schema the stub provider reports over protocol
+// version, as decoded by GetProviderSchema.
+func stubSchema(version int) *coreschema.ProviderSchema {
+	attribute := func(typ string, a coreschema.Attribute) *coreschema.Attribute {
+		a.AttributeType = json.RawMessage(typ)
+		if a.DescriptionKind == "" {
+			a.DescriptionKind = coreschema.DescriptionPlain
+		}
+		return &a
+	}
+	thing := &coreschema.Block{
+		Description:     "A *thing*",
+		DescriptionKind: coreschema.DescriptionMarkdown,
+		Attributes: map[string]*coreschema.Attribute{
+			"id":     attribute(`"string"`, coreschema.Attribute{Computed: true}),
+			"name":   attribute(`"string"`, coreschema.Attribute{Required: true, Sensitive: true, Description: "The name"}),
+			"legacy": attribute(`"bool"`, coreschema.Attribute{Optional: true, Deprecated: true}),
+			"ports":  attribute(`["list","number"]`, coreschema.Attribute{Optional: true}),
+		},
+		BlockTypes: map[string]*coreschema.BlockType{
+			"rule": {NestingMode: coreschema.NestingSet, MaxItems: 3, Block: &coreschema.Block{
+				DescriptionKind: coreschema.DescriptionPlain,
+				Attributes: map[string]*coreschema.Attribute{
+					"cidr": attribute(`"string"`, coreschema.Attribute{Required: true}),
+				},
+			}},
+			"settings": {NestingMode: coreschema.NestingSingle, Block: &coreschema.Block{
+				DescriptionKind: coreschema.DescriptionPlain,
+				Deprecated:      true,
+				Attributes: map[string]*coreschema.Attribute{
+					"key": attribute(`"string"`, coreschema.Attribute{Optional: true}),
+				},
+				BlockTypes: map[string]*coreschema.BlockType{
+					"option": {NestingMode: coreschema.NestingList, MinItems: 1, Block: &coreschema.Block{
+						DescriptionKind: coreschema.DescriptionPlain,
+						Attributes: map[string]*coreschema.Attribute{
+							"value": attribute(`"number"`, coreschema.Attribute{Computed: true}),
+						},
+					}},
+				},
+			}},
+		},
+	}
+	if version >= 6 {
+		thing.Attributes["endpoint"] = &coreschema.Attribute{
+			Optional:        true,
+			DescriptionKind: coreschema.DescriptionPlain,
+			NestedType: &coreschema.NestedType{
+				NestingMode: coreschema.NestingSingle,
+				Attributes: map[string]*coreschema.Attribute{
+					"url":      attribute(`"string"`, coreschema.Attribute{Required: true}),
+					"password": attribute(`"string"`, coreschema.Attribute{Optional: true, Sensitive: true}),
+				},
+			},
+		}
+		thing.Attributes["listeners"] = &coreschema.Attribute{
+			Optional:        true,
+			DescriptionKind: coreschema.DescriptionPlain,
+			NestedType: &coreschema.NestedType{
+				NestingMode: coreschema.NestingList,
+				MinItems:    1,
+				MaxItems:    4,
+				Attributes: map[string]*coreschema.Attribute{
+					"port": attribute(`"number"`, coreschema.Attribute{Required: true, Description: "The port", DescriptionKind: coreschema.DescriptionMarkdown}),
+				},
+			},
+		}
+	}
+
+	return &coreschema.ProviderSchema{
+		Provider: &coreschema.Schema{Block: &coreschema.Block{
+			DescriptionKind: coreschema.DescriptionPlain,
+			Attributes: map[string]*coreschema.Attribute{
+				"region": attribute(`"string"`, coreschema.Attribute{Optional: true, Description: "The region"}),
+				"token":  attribute(`"string"`, coreschema.Attribute{Optional: true, Sensitive: true}),
+			},
+		}},
+		ProviderMeta: &coreschema.Schema{Block: &coreschema.Block{
+			DescriptionKind: coreschema.DescriptionPlain,
+			Attributes: map[string]*coreschema.Attribute{
+				"module_name": attribute(`"string"`, coreschema.Attribute{Optional: true}),
+			},
+		}},
+		ResourceSchemas: map[string]*coreschema.Schema{
+			"stub_thing": {Version: 2, Block: thing},
+		},
+		DataSourceSchemas: map[string]*coreschema.Schema{
+			"stub_info": {Block: &coreschema.Block{
+				DescriptionKind: coreschema.DescriptionPlain,
+				Attributes: map[string]*coreschema.Attribute{
+					"id": attribute(`"string"`, coreschema.Attribute{Computed: true}),
+				},
+			}},
+		},
+	}
+}
+
+// thingDefinitions are the attributes of the stub_thing resource
+// exported from the stub provider over protocol version.
+func thingDefinitions(version int) SchemaInfo {
+	info := SchemaInfo{
+		"id":     {Type: "String", Computed: true},
+		"name":   {Type: "String", Required: true, Sensitive: true, Description: "The name", DescriptionKind: "plain"},
+		"legacy": {Type: "Bool", Optional: true, Deprecated: "Deprecated"},
+		"ports": {
+			Type:               "List",
+			Optional:           true,
+			ConfigImplicitMode: "Attr",
+			Elem:               &SchemaElement{Type: "SchemaElements", ElementsType: "Float", Elements: &SchemaDefinition{Type: "Float"}},
+		},
+		"rule": {
+			Type:               "Set",
+			Optional:           true,
+			MaxItems:           3,
+			IsBlock:            true,
+			ConfigImplicitMode: "Block",
+			Elem: &SchemaElement{Type: "SchemaInfo", Info: SchemaInfo{
+				"cidr": {Type: "String", Required: true},
+			}},
+		},
+		"settings": {
+			Type:               "List",
+			Optional:           true,
+			MaxItems:           1,
+			IsBlock:            true,
+			ConfigImplicitMode: "Block",
+			Nesting:            model.NestingSingle,
+			Deprecated:         "Deprecated",
+			Elem: &SchemaElement{Type: "SchemaInfo", Info: SchemaInfo{
+				"key": {Type: "String", Optional: true},
+				"option": {
+					Type:               "List",
+					Required:           true,
+					MinItems:           1,
+					IsBlock:            true,
+					ConfigImplicitMode: "Block",
+					Elem: &SchemaElement{Type: "SchemaInfo", Info: SchemaInfo{
+						"value": {Type: "Float", Computed: true},
+					}},
+				},
+			}},
+		},
+	}
+	if version >= 6 {
+		info["endpoint"] = SchemaDefinition{
+			Type:               "List",
+			Optional:           true,
+			MaxItems:           1,
+			ConfigImplicitMode: "Attr",
+			Nesting:            model.NestingSingle,
+			Elem: &SchemaElement{Type: "SchemaInfo", Info: SchemaInfo{
+				"url":      {Type: "String", Required: true},
+				"password": {Type: "String", Optional: true, Sensitive: true},
+			}},
+		}
+		info["listeners"] = SchemaDefinition{
+			Type:               "List",
+			Optional:           true,
+			MinItems:           1,
+			MaxItems:           4,
+			ConfigImplicitMode: "Attr",
+			Elem: &SchemaElement{Type: "SchemaInfo", Info: SchemaInfo{
+				"port": {Type: "Float", Required: true, Description: "The port", DescriptionKind: "markdown"},
+			}},
+		}
+	}
+	return info
+}
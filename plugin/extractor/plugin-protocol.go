@@ -0,0 +1,328 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package extractor
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/evan-cleary/tf-schema-extractor/coreschema"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The GetProviderSchema response is decoded straight from the protobuf
+// wire format, following tfplugin5.proto and tfplugin6.proto. The field
+// numbers of the schema messages are the same in both protocols;
+// protocol 6 adds nested attribute types. Fields with their zero value
+// are not encoded, so defaults such as the plain description kind are
+// set before decoding.
+
+// rawCodec passes messages through as encoded bytes.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	return *v.(*[]byte), nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	*v.(*[]byte) = append([]byte(nil), data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
+
+// fieldFunc receives one field of a message. value holds the payload of
+// length-delimited fields, number that of varint fields.
+type fieldFunc func(num protowire.Number, value []byte, number uint64) error
+
+// decodeFields calls fn for each varint and length-delimited field of
+// the message b, skipping fields of other wire types.
+func decodeFields(b []byte, fn fieldFunc) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			if err := fn(num, nil, v); err != nil {
+				return err
+			}
+			b = b[n:]
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			if err := fn(num, v, 0); err != nil {
+				return err
+			}
+			b = b[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			b = b[n:]
+		}
+	}
+	return nil
+}
+
+// decodeProviderSchema decodes a GetProviderSchema.Response. Errors
+// reported in its diagnostics are returned as the error.
+func decodeProviderSchema(b []byte) (*coreschema.ProviderSchema, error) {
+	result := &coreschema.ProviderSchema{
+		ResourceSchemas:   make(map[string]*coreschema.Schema),
+		DataSourceSchemas: make(map[string]*coreschema.Schema),
+	}
+	var diagnostics []string
+	err := decodeFields(b, func(num protowire.Number, value []byte, _ uint64) error {
+		var err error
+		switch num {
+		case 1:
+			result.Provider, err = decodeSchema(value)
+		case 2:
+			err = decodeSchemaEntry(value, result.ResourceSchemas)
+		case 3:
+			err = decodeSchemaEntry(value, result.DataSourceSchemas)
+		case 4:
+			var summary string
+			summary, err = decodeErrorDiagnostic(value)
+			if summary != "" {
+				diagnostics = append(diagnostics, summary)
+			}
+		case 5:
+			result.ProviderMeta, err = decodeSchema(value)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(diagnostics) > 0 {
+		return nil, fmt.Errorf("provider reported errors: %v", diagnostics)
+	}
+	return result, nil
+}
+
+// decodeSchemaEntry decodes an entry of a map<string, Schema> field.
+func decodeSchemaEntry(b []byte, schemas map[string]*coreschema.Schema) error {
+	var name string
+	var s *coreschema.Schema
+	err := decodeFields(b, func(num protowire.Number, value []byte, _ uint64) error {
+		var err error
+		switch num {
+		case 1:
+			name = string(value)
+		case 2:
+			s, err = decodeSchema(value)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		s = &coreschema.Schema{}
+	}
+	schemas[name] = s
+	return nil
+}
+
+// decodeErrorDiagnostic returns the summary and detail of an error
+// diagnostic, or "" for warnings.
+func decodeErrorDiagnostic(b []byte) (string, error) {
+	var severity uint64
+	var summary, detail string
+	err := decodeFields(b, func(num protowire.Number, value []byte, number uint64) error {
+		switch num {
+		case 1:
+			severity = number
+		case 2:
+			summary = string(value)
+		case 3:
+			detail = string(value)
+		}
+		return nil
+	})
+	if err != nil || severity != 1 {
+		return "", err
+	}
+	if detail != "" {
+		summary += ": " + detail
+	}
+	return summary, nil
+}
+
+func decodeSchema(b []byte) (*coreschema.Schema, error) {
+	s := &coreschema.Schema{}
+	err := decodeFields(b, func(num protowire.Number, value []byte, number uint64) error {
+		var err error
+		switch num {
+		case 1:
+			s.Version = number
+		case 2:
+			s.Block, err = decodeBlock(value)
+		}
+		return err
+	})
+	return s, err
+}
+
+func decodeBlock(b []byte) (*coreschema.Block, error) {
+	block := &coreschema.Block{
+		Attributes:      make(map[string]*coreschema.Attribute),
+		BlockTypes:      make(map[string]*coreschema.BlockType),
+		DescriptionKind: coreschema.DescriptionPlain,
+	}
+	err := decodeFields(b, func(num protowire.Number, value []byte, number uint64) error {
+		switch num {
+		case 2:
+			name, a, err := decodeAttribute(value)
+			if err != nil {
+				return err
+			}
+			block.Attributes[name] = a
+		case 3:
+			name, bt, err := decodeBlockType(value)
+			if err != nil {
+				return err
+			}
+			block.BlockTypes[name] = bt
+		case 4:
+			block.Description = string(value)
+		case 5:
+			block.DescriptionKind = stringKind(number)
+		case 6:
+			block.Deprecated = number != 0
+		}
+		return nil
+	})
+	return block, err
+}
+
+func decodeAttribute(b []byte) (string, *coreschema.Attribute, error) {
+	var name string
+	a := &coreschema.Attribute{DescriptionKind: coreschema.DescriptionPlain}
+	err := decodeFields(b, func(num protowire.Number, value []byte, number uint64) error {
+		var err error
+		switch num {
+		case 1:
+			name = string(value)
+		case 2:
+			a.AttributeType = append([]byte(nil), value...)
+		case 3:
+			a.Description = string(value)
+		case 4:
+			a.Required = number != 0
+		case 5:
+			a.Optional = number != 0
+		case 6:
+			a.Computed = number != 0
+		case 7:
+			a.Sensitive = number != 0
+		case 8:
+			a.DescriptionKind = stringKind(number)
+		case 9:
+			a.Deprecated = number != 0
+		case 10:
+			a.NestedType, err = decodeNestedType(value)
+		}
+		return err
+	})
+	if err == nil && name == "" {
+		err = errors.New("attribute without a name")
+	}
+	return name, a, err
+}
+
+func decodeNestedType(b []byte) (*coreschema.NestedType, error) {
+	nt := &coreschema.NestedType{Attributes: make(map[string]*coreschema.Attribute)}
+	err := decodeFields(b, func(num protowire.Number, value []byte, number uint64) error {
+		switch num {
+		case 1:
+			name, a, err := decodeAttribute(value)
+			if err != nil {
+				return err
+			}
+			nt.Attributes[name] = a
+		case 3:
+			nt.NestingMode = nestingMode(number)
+		case 4:
+			nt.MinItems = number
+		case 5:
+			nt.MaxItems = number
+		}
+		return nil
+	})
+	return nt, err
+}
+
+func decodeBlockType(b []byte) (string, *coreschema.BlockType, error) {
+	var name string
+	bt := &coreschema.BlockType{}
+	err := decodeFields(b, func(num protowire.Number, value []byte, number uint64) error {
+		var err error
+		switch num {
+		case 1:
+			name = string(value)
+		case 2:
+			bt.Block, err = decodeBlock(value)
+		case 3:
+			bt.NestingMode = nestingMode(number)
+		case 4:
+			bt.MinItems = number
+		case 5:
+			bt.MaxItems = number
+		}
+		return err
+	})
+	if err == nil && name == "" {
+		err = errors.New("block type without a name")
+	}
+	return name, bt, err
+}
+
+func nestingMode(value uint64) string {
+	switch value {
+	case 1:
+		return coreschema.NestingSingle
+	case 2:
+		return coreschema.NestingList
+	case 3:
+		return coreschema.NestingSet
+	case 4:
+		return coreschema.NestingMap
+	case 5:
+		return coreschema.NestingGroup
+	}
+	return "invalid"
+}
+
+func stringKind(value uint64) string {
+	if value == 1 {
+		return coreschema.DescriptionMarkdown
+	}
+	return coreschema.DescriptionPlain
+}
@@ -0,0 +1,185 @@
+/*
+      Copyright 2021 Evan Cleary
+
+      Licensed under the Apache License, Version 2.0 (the "License");
+      you may not use this file except in compliance with the License.
+      You may obtain a copy of the License at
+
+          http://www.apache.org/licenses/LICENSE-2.0
+
+      Unless required by applicable law or agreed to in writing, software
+      distributed under the License is distributed on an "AS IS" BASIS,
+      WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+      See the License for the specific language governing permissions and
+      limitations under the License.
+*/
+
+// The stub provider serves a fixed schema over plugin protocol 5 or 6,
+// as set with -ldflags "-X main.protocol=6". Protocol 5 has no nested
+// attribute types, so those are left out of its schema.
+package main
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5/tf5server"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6/tf6server"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+var protocol = "5"
+
+const address = "registry.terraform.io/test/stub"
+
+func main() {
+	if protocol == "6" {
+		tf6server.Serve(address, func() tfprotov6.ProviderServer { return provider6{} })
+	} else {
+		tf5server.Serve(address, func() tfprotov5.ProviderServer { return provider5{} })
+	}
+}
+
+// The other RPCs are never called by the extractor.
+type provider5 struct {
+	tfprotov5.ProviderServer
+}
+
+type provider6 struct {
+	tfprotov6.ProviderServer
+}
+
+func (provider6) GetProviderSchema(context.Context, *tfprotov6.GetProviderSchemaRequest) (*tfprotov6.GetProviderSchemaResponse, error) {
+	return &tfprotov6.GetProviderSchemaResponse{
+		Provider:     &tfprotov6.Schema{Block: providerBlock()},
+		ProviderMeta: &tfprotov6.Schema{Block: providerMetaBlock()},
+		ResourceSchemas: map[string]*tfprotov6.Schema{
+			"stub_thing": {Version: 2, Block: thingBlock()},
+		},
+		DataSourceSchemas: map[string]*tfprotov6.Schema{
+			"stub_info": {Block: infoBlock()},
+		},
+	}, nil
+}
+
+func (provider5) GetProviderSchema(context.Context, *tfprotov5.GetProviderSchemaRequest) (*tfprotov5.GetProviderSchemaResponse, error) {
+	return &tfprotov5.GetProviderSchemaResponse{
+		Provider:     &tfprotov5.Schema{Block: block5(providerBlock())},
+		ProviderMeta: &tfprotov5.Schema{Block: block5(providerMetaBlock())},
+		ResourceSchemas: map[string]*tfprotov5.Schema{
+			"stub_thing": {Version: 2, Block: block5(thingBlock())},
+		},
+		DataSourceSchemas: map[string]*tfprotov5.Schema{
+			"stub_info": {Block: block5(infoBlock())},
+		},
+	}, nil
+}
+
+func providerBlock() *tfprotov6.SchemaBlock {
+	return &tfprotov6.SchemaBlock{
+		Attributes: []*tfprotov6.SchemaAttribute{
+			{Name: "region", Type: tftypes.String, Optional: true, Description: "The region"},
+			{Name: "token", Type: tftypes.String, Optional: true, Sensitive: true},
+		},
+	}
+}
+
+func providerMetaBlock() *tfprotov6.SchemaBlock {
+	return &tfprotov6.SchemaBlock{
+		Attributes: []*tfprotov6.SchemaAttribute{
+			{Name: "module_name", Type: tftypes.String, Optional: true},
+		},
+	}
+}
+
+func thingBlock() *tfprotov6.SchemaBlock {
+	return &tfprotov6.SchemaBlock{
+		Description:     "A *thing*",
+		DescriptionKind: tfprotov6.StringKindMarkdown,
+		Attributes: []*tfprotov6.SchemaAttribute{
+			{Name: "id", Type: tftypes.String, Computed: true},
+			{Name: "name", Type: tftypes.String, Required: true, Sensitive: true, Description: "The name"},
+			{Name: "legacy", Type: tftypes.Bool, Optional: true, Deprecated: true},
+			{Name: "ports", Type: tftypes.List{ElementType: tftypes.Number}, Optional: true},
+			{Name: "endpoint", Optional: true, NestedType: &tfprotov6.SchemaObject{
+				Nesting: tfprotov6.SchemaObjectNestingModeSingle,
+				Attributes: []*tfprotov6.SchemaAttribute{
+					{Name: "url", Type: tftypes.String, Required: true},
+					{Name: "password", Type: tftypes.String, Optional: true, Sensitive: true},
+				},
+			}},
+			{Name: "listeners", Optional: true, NestedType: &tfprotov6.SchemaObject{
+				Nesting:  tfprotov6.SchemaObjectNestingModeList,
+				MinItems: 1,
+				MaxItems: 4,
+				Attributes: []*tfprotov6.SchemaAttribute{
+					{Name: "port", Type: tftypes.Number, Required: true, Description: "The port", DescriptionKind: tfprotov6.StringKindMarkdown},
+				},
+			}},
+		},
+		BlockTypes: []*tfprotov6.SchemaNestedBlock{
+			{TypeName: "rule", Nesting: tfprotov6.SchemaNestedBlockNestingModeSet, MaxItems: 3, Block: &tfprotov6.SchemaBlock{
+				Attributes: []*tfprotov6.SchemaAttribute{
+					{Name: "cidr", Type: tftypes.String, Required: true},
+				},
+			}},
+			{TypeName: "settings", Nesting: tfprotov6.SchemaNestedBlockNestingModeSingle, Block: &tfprotov6.SchemaBlock{
+				Deprecated: true,
+				Attributes: []*tfprotov6.SchemaAttribute{
+					{Name: "key", Type: tftypes.String, Optional: true},
+				},
+				BlockTypes: []*tfprotov6.SchemaNestedBlock{
+					{TypeName: "option", Nesting: tfprotov6.SchemaNestedBlockNestingModeList, MinItems: 1, Block: &tfprotov6.SchemaBlock{
+						Attributes: []*tfprotov6.SchemaAttribute{
+							{Name: "value", Type: tftypes.Number, Computed: true},
+						},
+					}},
+				},
+			}},
+		},
+	}
+}
+
+func infoBlock() *tfprotov6.SchemaBlock {
+	return &tfprotov6.SchemaBlock{
+		Attributes: []*tfprotov6.SchemaAttribute{
+			{Name: "id", Type: tftypes.String, Computed: true},
+		},
+	}
+}
+
+// block5 converts b to protocol 5, dropping attributes of nested types.
+func block5(b *tfprotov6.SchemaBlock) *tfprotov5.SchemaBlock {
+	result := &tfprotov5.SchemaBlock{
+		Description:     b.Description,
+		DescriptionKind: tfprotov5.StringKind(b.DescriptionKind),
+		Deprecated:      b.Deprecated,
+	}
+	for _, a := range b.Attributes {
+		if a.NestedType != nil {
+			continue
+		}
+		result.Attributes = append(result.Attributes, &tfprotov5.SchemaAttribute{
+			Name:            a.Name,
+			Type:            a.Type,
+			Description:     a.Description,
+			Required:        a.Required,
+			Optional:        a.Optional,
+			Computed:        a.Computed,
+			Sensitive:       a.Sensitive,
+			DescriptionKind: tfprotov5.StringKind(a.DescriptionKind),
+			Deprecated:      a.Deprecated,
+		})
+	}
+	for _, bt := range b.BlockTypes {
+		result.BlockTypes = append(result.BlockTypes, &tfprotov5.SchemaNestedBlock{
+			TypeName: bt.TypeName,
+			Block:    block5(bt.Block),
+			Nesting:  tfprotov5.SchemaNestedBlockNestingMode(bt.Nesting),
+			MinItems: bt.MinItems,
+			MaxItems: bt.MaxItems,
+		})
+	}
+	return result
+}
@@ -0,0 +1,127 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package schemadiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriteFunc writes a Result in one output format.
+type WriteFunc func(r *Result, w io.Writer) error
+
+// Formats are the output formats of a Result by name.
+var Formats = map[string]WriteFunc{
+	"text":     WriteText,
+	"json":     WriteJSON,
+	"markdown": WriteMarkdown,
+}
+
+// FormatNames lists the names of Formats, sorted.
+func FormatNames() []string {
+	names := make([]string, 0, len(Formats))
+	for name := range Formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var symbols = map[string]string{
+	Added:   "+",
+	Removed: "-",
+	Changed: "~",
+}
+
+// WriteText writes the changes one per line, breaking changes first.
+func WriteText(r *Result, w io.Writer) error {
+	breaking, other := r.Split()
+	var b strings.Builder
+	for _, group := range []struct {
+		title   string
+		changes []Change
+	}{
+		{"Breaking changes:", breaking},
+		{"Other changes:", other},
+	} {
+		if len(group.changes) == 0 {
+			continue
+		}
+		b.WriteString(group.title + "\n")
+		for _, c := range group.changes {
+			fmt.Fprintf(&b, "  %s %s: %s\n", symbols[c.Kind], c.Subject(), c.Description)
+		}
+	}
+	fmt.Fprintf(&b, "%d breaking, %d other changes\n", len(breaking), len(other))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the Result as a JSON document.
+func WriteJSON(r *Result, w io.Writer) error {
+	if r.Changes == nil {
+		r = &Result{Changes: []Change{}}
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	_, err = w.Write(data)
+	return err
+}
+
+// WriteMarkdown writes the changes as Markdown lists, breaking changes
+// first, e.g. for a pull request comment.
+func WriteMarkdown(r *Result, w io.Writer) error {
+	breaking, other := r.Split()
+	var b strings.Builder
+	b.WriteString("## Schema Changes\n")
+	if len(r.Changes) == 0 {
+		b.WriteString("\nNo changes.\n")
+	}
+	for _, group := range []struct {
+		title   string
+		changes []Change
+	}{
+		{"### Breaking Changes", breaking},
+		{"### Other Changes", other},
+	} {
+		if len(group.changes) == 0 {
+			continue
+		}
+		b.WriteString("\n" + group.title + "\n\n")
+		for _, c := range group.changes {
+			fmt.Fprintf(&b, "* %s: %s\n", markdownSubject(c), c.Description)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func markdownSubject(c Change) string {
+	subject := c.Scope
+	if c.Resource != "" {
+		subject += " `" + c.Resource + "`"
+	}
+	if c.Path != "" {
+		subject += " attribute `" + c.Path + "`"
+	}
+	return subject
+}
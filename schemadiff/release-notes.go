@@ -0,0 +1,201 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package schemadiff
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Values returned by Bump.
+const (
+	BumpMajor = "major"
+	BumpMinor = "minor"
+	BumpPatch = "patch"
+)
+
+// Bump recommends the semantic version increment for a release with the
+// changes of r after version current: major for breaking changes, minor
+// for new resources, data sources, attributes and deprecations, and
+// patch otherwise, since fixes do not show in the schema. Before 1.0.0
+// breaking changes only bump the minor version and everything else the
+// patch version. current may be empty if unknown.
+func Bump(r *Result, current string) string {
+	breaking, features := false, false
+	for _, c := range r.Changes {
+		breaking = breaking || c.Breaking
+		features = features || c.Kind == Added || isDeprecation(c)
+	}
+	if v, err := parseVersion(current); err == nil && v[0] == 0 {
+		if breaking {
+			return BumpMinor
+		}
+		return BumpPatch
+	}
+	switch {
+	case breaking:
+		return BumpMajor
+	case features:
+		return BumpMinor
+	}
+	return BumpPatch
+}
+
+// NextVersion increments current by bump, keeping a leading "v".
+func NextVersion(current, bump string) (string, error) {
+	v, err := parseVersion(current)
+	if err != nil {
+		return "", err
+	}
+	switch bump {
+	case BumpMajor:
+		v = [3]int{v[0] + 1, 0, 0}
+	case BumpMinor:
+		v = [3]int{v[0], v[1] + 1, 0}
+	case BumpPatch:
+		v = [3]int{v[0], v[1], v[2] + 1}
+	default:
+		return "", fmt.Errorf("unknown version increment %q", bump)
+	}
+	prefix := ""
+	if strings.HasPrefix(current, "v") {
+		prefix = "v"
+	}
+	return fmt.Sprintf("%s%d.%d.%d", prefix, v[0], v[1], v[2]), nil
+}
+
+// parseVersion parses a "MAJOR.MINOR.PATCH" version, ignoring a leading
+// "v" and any pre-release or build suffix.
+func parseVersion(version string) ([3]int, error) {
+	var result [3]int
+	core := strings.TrimPrefix(version, "v")
+	if i := strings.IndexAny(core, "-+"); i >= 0 {
+		core = core[:i]
+	}
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return result, fmt.Errorf("invalid version %q, expected MAJOR.MINOR.PATCH", version)
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return result, fmt.Errorf("invalid version %q, expected MAJOR.MINOR.PATCH", version)
+		}
+		result[i] = n
+	}
+	return result, nil
+}
+
+func isDeprecation(c Change) bool {
+	return c.Kind == Changed && c.After != nil && (c.Field == "Deprecated" || c.Field == "DeprecationMessage")
+}
+
+// WriteChangelog drafts the CHANGELOG section of a release with the
+// changes of r in the style of HashiCorp's providers: breaking changes,
+// notes on deprecations, new resources and data sources as features and
+// new attributes as enhancements. Other changes are left out. version
+// heads the section; if it is empty, the section is headed "Unreleased"
+// with the bump the changes call for.
+func WriteChangelog(r *Result, version string, w io.Writer) error {
+	var breaking, notes, features, enhancements []string
+	for _, c := range r.Changes {
+		switch {
+		case c.Breaking:
+			breaking = append(breaking, changelogEntry(c))
+		case isDeprecation(c):
+			notes = append(notes, changelogEntry(c))
+		case c.Kind == Added && c.Path == "":
+			features = append(features, changelogEntry(c))
+		case c.Kind == Added:
+			enhancements = append(enhancements, changelogEntry(c))
+		}
+	}
+
+	var b strings.Builder
+	if version == "" {
+		fmt.Fprintf(&b, "## Unreleased (%s)\n", Bump(r, ""))
+	} else {
+		fmt.Fprintf(&b, "## %s (Unreleased)\n", strings.TrimPrefix(version, "v"))
+	}
+	for _, section := range []struct {
+		title   string
+		entries []string
+	}{
+		{"BREAKING CHANGES", breaking},
+		{"NOTES", notes},
+		{"FEATURES", features},
+		{"ENHANCEMENTS", enhancements},
+	} {
+		if len(section.entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s:\n\n", section.title)
+		for _, entry := range section.entries {
+			fmt.Fprintf(&b, "* %s\n", entry)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// changelogEntry describes c, prefixed with the resource it affects as
+// in "resource/aws_instance: ...".
+func changelogEntry(c Change) string {
+	if c.Kind == Added && c.Path == "" {
+		if c.Scope == ScopeDataSource {
+			return fmt.Sprintf("**New Data Source:** `%s`", c.Resource)
+		}
+		return fmt.Sprintf("**New Resource:** `%s`", c.Resource)
+	}
+
+	var prefix string
+	switch c.Scope {
+	case ScopeProvider:
+		prefix = "provider"
+	case ScopeDataSource:
+		prefix = "data-source/" + c.Resource
+	default:
+		prefix = "resource/" + c.Resource
+	}
+
+	if c.Path == "" {
+		switch {
+		case c.Kind == Removed:
+			return fmt.Sprintf("%s: Remove the %s", prefix, c.Scope)
+		case isDeprecation(c):
+			return fmt.Sprintf("%s: Deprecate the %s: %v", prefix, c.Scope, c.After)
+		}
+		return fmt.Sprintf("%s: The %s %s", prefix, c.Scope, c.Description)
+	}
+
+	noun := "argument"
+	if c.ReadOnly {
+		noun = "attribute"
+	}
+	switch {
+	case c.Kind == Added && c.Breaking:
+		return fmt.Sprintf("%s: Add required `%s` argument", prefix, c.Path)
+	case c.Kind == Added:
+		return fmt.Sprintf("%s: Add `%s` %s", prefix, c.Path, noun)
+	case c.Kind == Removed:
+		return fmt.Sprintf("%s: Remove `%s`", prefix, c.Path)
+	case isDeprecation(c):
+		return fmt.Sprintf("%s: Deprecate `%s`: %v", prefix, c.Path, c.After)
+	}
+	return fmt.Sprintf("%s: `%s` %s", prefix, c.Path, c.Description)
+}
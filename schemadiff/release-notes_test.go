@@ -0,0 +1,176 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package schemadiff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/evan-cleary/tf-schema-extractor/model"
+)
+
+func TestNextVersion(t *testing.T) {
+	tests := []struct {
+		current, bump string
+		want          string
+		err           bool
+	}{
+		{current: "1.2.3", bump: BumpMajor, want: "2.0.0"},
+		{current: "1.2.3", bump: BumpMinor, want: "1.3.0"},
+		{current: "1.2.3", bump: BumpPatch, want: "1.2.4"},
+		{current: "v1.2.3", bump: BumpMinor, want: "v1.3.0"},
+		{current: "0.9.1", bump: BumpMinor, want: "0.10.0"},
+		{current: "0.0.1", bump: BumpPatch, want: "0.0.2"},
+		{current: "v2.0.0-beta.1", bump: BumpPatch, want: "v2.0.1"},
+		{current: "1.2.3+build.5", bump: BumpMajor, want: "2.0.0"},
+		{current: "", bump: BumpPatch, err: true},
+		{current: "1.2", bump: BumpPatch, err: true},
+		{current: "1.2.x", bump: BumpPatch, err: true},
+		{current: "1.-2.3", bump: BumpPatch, err: true},
+		{current: "latest", bump: BumpPatch, err: true},
+		{current: "1.2.3", bump: "huge", err: true},
+	}
+	for _, test := range tests {
+		got, err := NextVersion(test.current, test.bump)
+		if test.err {
+			if err == nil {
+				t.Errorf("NextVersion(%q, %q) = %q, want an error", test.current, test.bump, got)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("NextVersion(%q, %q) = %q, %v; want %q", test.current, test.bump, got, err, test.want)
+		}
+	}
+}
+
+// Changes as Compare reports them.
+var (
+	newResource       = Change{Kind: Added, Scope: ScopeResource, Resource: "test_new", Description: "resource added"}
+	newDataSource     = Change{Kind: Added, Scope: ScopeDataSource, Resource: "test_info", Description: "data source added"}
+	newArgument       = Change{Kind: Added, Scope: ScopeResource, Resource: "test_thing", Path: "size", Description: "attribute added"}
+	newAttribute      = Change{Kind: Added, Scope: ScopeResource, Resource: "test_thing", Path: "arn", ReadOnly: true, Description: "attribute added"}
+	newRequired       = Change{Kind: Added, Scope: ScopeResource, Resource: "test_thing", Path: "zone", Breaking: true, Description: "required attribute added"}
+	removedAttribute  = Change{Kind: Removed, Scope: ScopeResource, Resource: "test_thing", Path: "tier", Breaking: true, Description: "attribute removed"}
+	deprecated        = Change{Kind: Changed, Scope: ScopeResource, Resource: "test_thing", Path: "name", Field: "Deprecated", After: "Use label", Description: "deprecated: Use label"}
+	deprecatedSource  = Change{Kind: Changed, Scope: ScopeDataSource, Resource: "test_old", Field: "DeprecationMessage", After: "Use test_info", Description: "deprecated: Use test_info"}
+	describedArgument = Change{Kind: Changed, Scope: ScopeResource, Resource: "test_thing", Path: "name", Field: "Description", Description: "description changed"}
+)
+
+func TestBump(t *testing.T) {
+	tests := []struct {
+		name    string
+		changes []Change
+		current string
+		want    string
+	}{
+		{"no changes", nil, "1.2.3", BumpPatch},
+		{"description", []Change{describedArgument}, "1.2.3", BumpPatch},
+		{"new resource", []Change{newResource}, "1.2.3", BumpMinor},
+		{"new attribute", []Change{newAttribute}, "1.2.3", BumpMinor},
+		{"deprecation", []Change{deprecated}, "1.2.3", BumpMinor},
+		{"breaking", []Change{newArgument, removedAttribute}, "1.2.3", BumpMajor},
+		{"required argument", []Change{newRequired}, "v1.2.3", BumpMajor},
+		{"unknown version", []Change{removedAttribute}, "", BumpMajor},
+		{"invalid version", []Change{newResource}, "latest", BumpMinor},
+		{"pre-1.0 breaking", []Change{newArgument, removedAttribute}, "0.4.0", BumpMinor},
+		{"pre-1.0 feature", []Change{newResource}, "v0.4.0", BumpPatch},
+		{"pre-1.0 description", []Change{describedArgument}, "0.4.0", BumpPatch},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Bump(&Result{Changes: test.changes}, test.current); got != test.want {
+				t.Errorf("Bump(%q) = %s, want %s", test.current, got, test.want)
+			}
+		})
+	}
+}
+
+func TestBumpCompare(t *testing.T) {
+	optional := model.SchemaDefinition{Type: "String", Optional: true}
+	before := model.SchemaInfoWithTimeouts{"name": optional}
+	tests := []struct {
+		name  string
+		after model.SchemaInfoWithTimeouts
+		want  string
+	}{
+		{"unchanged", model.SchemaInfoWithTimeouts{"name": optional}, BumpPatch},
+		{"optional added", model.SchemaInfoWithTimeouts{"name": optional, "size": {Type: "Int", Optional: true}}, BumpMinor},
+		{"required added", model.SchemaInfoWithTimeouts{"name": optional, "size": {Type: "Int", Required: true}}, BumpMajor},
+		{"removed", model.SchemaInfoWithTimeouts{}, BumpMajor},
+		{"type changed", model.SchemaInfoWithTimeouts{"name": {Type: "Int", Optional: true}}, BumpMajor},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Bump(compareResource(before, test.after), "1.0.0"); got != test.want {
+				t.Errorf("Bump() = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestWriteChangelog(t *testing.T) {
+	r := &Result{Changes: []Change{
+		newResource, newDataSource, newArgument, newAttribute, newRequired,
+		removedAttribute, deprecated, deprecatedSource, describedArgument,
+	}}
+	var b strings.Builder
+	if err := WriteChangelog(r, "v1.3.0", &b); err != nil {
+		t.Fatal(err)
+	}
+	want := `## 1.3.0 (Unreleased)
+
+BREAKING CHANGES:
+
+* resource/test_thing: Add required ` + "`zone`" + ` argument
+* resource/test_thing: Remove ` + "`tier`" + `
+
+NOTES:
+
+* resource/test_thing: Deprecate ` + "`name`" + `: Use label
+* data-source/test_old: Deprecate the data source: Use test_info
+
+FEATURES:
+
+* **New Resource:** ` + "`test_new`" + `
+* **New Data Source:** ` + "`test_info`" + `
+
+ENHANCEMENTS:
+
+* resource/test_thing: Add ` + "`size`" + ` argument
+* resource/test_thing: Add ` + "`arn`" + ` attribute
+`
+	if b.String() != want {
+		t.Errorf("WriteChangelog() =\n%s\nwant\n%s", b.String(), want)
+	}
+
+	b.Reset()
+	if err := WriteChangelog(&Result{Changes: []Change{newArgument}}, "", &b); err != nil {
+		t.Fatal(err)
+	}
+	want = "## Unreleased (minor)\n\nENHANCEMENTS:\n\n* resource/test_thing: Add `size` argument\n"
+	if b.String() != want {
+		t.Errorf("WriteChangelog() without a version =\n%s\nwant\n%s", b.String(), want)
+	}
+
+	b.Reset()
+	if err := WriteChangelog(&Result{Changes: []Change{describedArgument}}, "1.2.4", &b); err != nil {
+		t.Fatal(err)
+	}
+	if want = "## 1.2.4 (Unreleased)\n"; b.String() != want {
+		t.Errorf("WriteChangelog() without entries = %q, want %q", b.String(), want)
+	}
+}
@@ -0,0 +1,448 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package schemadiff
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/evan-cleary/tf-schema-extractor/model"
+)
+
+// Values of Change.Kind.
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Values of Change.Scope.
+const (
+	ScopeProvider   = "provider"
+	ScopeResource   = "resource"
+	ScopeDataSource = "data source"
+)
+
+// Change is a single difference between two schemas.
+type Change struct {
+	Kind  string `json:"kind"`
+	Scope string `json:"scope"`
+	// Name of the resource or data source; empty for the provider
+	Resource string `json:"resource,omitempty"`
+	// Dotted path of the attribute or block within it; empty if the
+	// resource or data source itself changed. Elements of collections
+	// are written as "*".
+	Path string `json:"path,omitempty"`
+	// The SchemaDefinition or ResourceHeader field that changed
+	Field  string      `json:"field,omitempty"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+	// Set for added attributes that cannot be configured, only read
+	ReadOnly bool `json:"read_only,omitempty"`
+	// Set if configurations or state valid for the old schema may not be
+	// for the new one
+	Breaking bool `json:"breaking"`
+	// What changed, e.g. "became required"
+	Description string `json:"description"`
+}
+
+// Subject names what changed, e.g. "resource aws_instance attribute ami".
+func (c Change) Subject() string {
+	subject := c.Scope
+	if c.Resource != "" {
+		subject += " " + c.Resource
+	}
+	if c.Path != "" {
+		subject += " attribute " + c.Path
+	}
+	return subject
+}
+
+// Result lists the changes between two schemas, ordered by scope,
+// resource and path.
+type Result struct {
+	Changes []Change `json:"changes"`
+}
+
+// Breaking reports whether any change is breaking.
+func (r *Result) Breaking() bool {
+	for _, c := range r.Changes {
+		if c.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// Split separates the breaking changes from the others.
+func (r *Result) Split() (breaking, other []Change) {
+	for _, c := range r.Changes {
+		if c.Breaking {
+			breaking = append(breaking, c)
+		} else {
+			other = append(other, c)
+		}
+	}
+	return breaking, other
+}
+
+// Compare lists the changes from before to after. Breaking changes are
+// removed resources, data sources and attributes, new required
+// attributes and blocks, attributes that became required or are no
+// longer configurable, type changes, new ForceNew flags, new
+// conflicts, changed defaults, removed allowed values, tighter item
+// limits and removed importers.
+func Compare(before, after *model.ResourceProviderSchema) *Result {
+	d := &differ{}
+	d.info(ScopeProvider, "", "", before.Provider, after.Provider)
+	d.resources(ScopeResource, before.Resources, after.Resources, before.ResourceHeaders, after.ResourceHeaders)
+	d.resources(ScopeDataSource, before.DataSources, after.DataSources, before.DataSourceHeaders, after.DataSourceHeaders)
+	return &Result{Changes: d.changes}
+}
+
+type differ struct {
+	changes []Change
+}
+
+func (d *differ) add(c Change) {
+	d.changes = append(d.changes, c)
+}
+
+func (d *differ) resources(scope string, before, after map[string]model.SchemaInfoWithTimeouts, beforeHeaders, afterHeaders map[string]model.ResourceHeader) {
+	names := make(map[string]bool)
+	for name := range before {
+		names[name] = true
+	}
+	for name := range after {
+		names[name] = true
+	}
+	for _, name := range sortedKeys(names) {
+		o, inBefore := before[name]
+		n, inAfter := after[name]
+		switch {
+		case !inBefore:
+			d.add(Change{Kind: Added, Scope: scope, Resource: name, Description: "added"})
+		case !inAfter:
+			d.add(Change{Kind: Removed, Scope: scope, Resource: name, Breaking: true, Description: "removed"})
+		default:
+			d.header(scope, name, beforeHeaders[name], afterHeaders[name])
+			d.info(scope, name, "", model.SchemaInfo(o), model.SchemaInfo(n))
+		}
+	}
+}
+
+func (d *differ) header(scope, name string, before, after model.ResourceHeader) {
+	change := func(field string, o, n interface{}, breaking bool, description string) {
+		d.add(Change{Kind: Changed, Scope: scope, Resource: name, Field: field, Before: o, After: n, Breaking: breaking, Description: description})
+	}
+	if before.DeprecationMessage != after.DeprecationMessage {
+		if before.DeprecationMessage == "" {
+			change("DeprecationMessage", nil, after.DeprecationMessage, false, "deprecated: "+after.DeprecationMessage)
+		} else if after.DeprecationMessage == "" {
+			change("DeprecationMessage", before.DeprecationMessage, nil, false, "no longer deprecated")
+		} else {
+			change("DeprecationMessage", before.DeprecationMessage, after.DeprecationMessage, false, "deprecation message changed")
+		}
+	}
+	if before.SchemaVersion != after.SchemaVersion {
+		change("SchemaVersion", before.SchemaVersion, after.SchemaVersion, false,
+			fmt.Sprintf("state schema version changed from %d to %d", before.SchemaVersion, after.SchemaVersion))
+	}
+	if before.Importable != after.Importable {
+		if after.Importable {
+			change("Importable", false, true, false, "can be imported")
+		} else {
+			change("Importable", true, false, true, "can no longer be imported")
+		}
+	}
+	if before.Description != after.Description {
+		change("Description", before.Description, after.Description, false, "description changed")
+	}
+}
+
+func (d *differ) info(scope, resource, path string, before, after model.SchemaInfo) {
+	names := make(map[string]bool)
+	for name := range before {
+		names[name] = true
+	}
+	for name := range after {
+		names[name] = true
+	}
+	for _, name := range sortedKeys(names) {
+		o, inBefore := before[name]
+		n, inAfter := after[name]
+		attributePath := join(path, name)
+		switch {
+		case !inBefore:
+			c := Change{Kind: Added, Scope: scope, Resource: resource, Path: attributePath, ReadOnly: !configurable(n), Description: "added"}
+			if required(n) {
+				c.Breaking = true
+				c.Description = "added as required"
+			}
+			d.add(c)
+		case !inAfter:
+			d.add(Change{Kind: Removed, Scope: scope, Resource: resource, Path: attributePath, Breaking: true, Description: "removed"})
+		default:
+			d.definition(scope, resource, attributePath, o, n)
+		}
+	}
+}
+
+// required reports whether a configuration must set d.
+func required(d model.SchemaDefinition) bool {
+	return d.Required || d.IsBlock && d.MinItems > 0 && !d.Optional
+}
+
+// configurable reports whether a configuration may set d.
+func configurable(d model.SchemaDefinition) bool {
+	return d.Required || d.Optional
+}
+
+func (d *differ) definition(scope, resource, path string, before, after model.SchemaDefinition) {
+	change := func(field string, o, n interface{}, breaking bool, description string) {
+		d.add(Change{Kind: Changed, Scope: scope, Resource: resource, Path: path, Field: field, Before: o, After: n, Breaking: breaking, Description: description})
+	}
+
+	if before.Type != after.Type {
+		change("Type", before.Type, after.Type, true, fmt.Sprintf("type changed from %s to %s", before.Type, after.Type))
+	}
+	if before.Nesting != after.Nesting {
+		change("Nesting", before.Nesting, after.Nesting, true, "nesting changed")
+	}
+	if before.IsBlock != after.IsBlock {
+		if after.IsBlock {
+			change("IsBlock", false, true, true, "became a block")
+		} else {
+			change("IsBlock", true, false, true, "became an attribute")
+		}
+	}
+	switch {
+	case !required(before) && required(after):
+		change("Required", false, true, true, "became required")
+	case required(before) && !required(after) && configurable(after):
+		change("Required", true, false, false, "became optional")
+	}
+	switch {
+	case configurable(before) && !configurable(after):
+		change("Computed", false, true, true, "is no longer configurable")
+	case !configurable(before) && configurable(after):
+		change("Computed", true, false, false, "became configurable")
+	}
+	if before.Computed != after.Computed && configurable(before) && configurable(after) {
+		change("Computed", before.Computed, after.Computed, false, fmt.Sprintf("computed changed to %t", after.Computed))
+	}
+	if before.ForceNew != after.ForceNew {
+		if after.ForceNew {
+			change("ForceNew", false, true, true, "now forces replacement")
+		} else {
+			change("ForceNew", true, false, false, "no longer forces replacement")
+		}
+	}
+	if before.Sensitive != after.Sensitive {
+		change("Sensitive", before.Sensitive, after.Sensitive, false, fmt.Sprintf("sensitive changed to %t", after.Sensitive))
+	}
+	if before.MaxItems != after.MaxItems {
+		change("MaxItems", before.MaxItems, after.MaxItems, tighter(before.MaxItems, after.MaxItems),
+			fmt.Sprintf("max items changed from %s to %s", limit(before.MaxItems), limit(after.MaxItems)))
+	}
+	if before.MinItems != after.MinItems {
+		change("MinItems", before.MinItems, after.MinItems, after.MinItems > before.MinItems,
+			fmt.Sprintf("min items changed from %d to %d", before.MinItems, after.MinItems))
+	}
+	if added := missing(after.ConflictsWith, before.ConflictsWith); len(added) > 0 {
+		change("ConflictsWith", before.ConflictsWith, after.ConflictsWith, true, "now conflicts with "+strings.Join(added, ", "))
+	} else if removed := missing(before.ConflictsWith, after.ConflictsWith); len(removed) > 0 {
+		change("ConflictsWith", before.ConflictsWith, after.ConflictsWith, false, "no longer conflicts with "+strings.Join(removed, ", "))
+	}
+	if before.Deprecated != after.Deprecated {
+		switch {
+		case before.Deprecated == "":
+			change("Deprecated", nil, after.Deprecated, false, "deprecated: "+after.Deprecated)
+		case after.Deprecated == "":
+			change("Deprecated", before.Deprecated, nil, false, "no longer deprecated")
+		default:
+			change("Deprecated", before.Deprecated, after.Deprecated, false, "deprecation message changed")
+		}
+	}
+	if !reflect.DeepEqual(defaultValue(before), defaultValue(after)) {
+		// Timeouts only bound how long Terraform waits
+		breaking := !strings.HasPrefix(path, model.TimeoutsConfigKey+".")
+		change("Default", defaultValue(before), defaultValue(after), breaking, defaultDescription(defaultValue(after)))
+	}
+	d.validation(change, before, after)
+	if before.Description != after.Description {
+		change("Description", before.Description, after.Description, false, "description changed")
+	}
+
+	d.elem(scope, resource, path, before.Elem, after.Elem)
+}
+
+func (d *differ) validation(change func(string, interface{}, interface{}, bool, string), before, after model.SchemaDefinition) {
+	var o, n []interface{}
+	if before.Validation != nil {
+		o = before.Validation.AllowedValues
+	}
+	if after.Validation != nil {
+		n = after.Validation.AllowedValues
+	}
+	if reflect.DeepEqual(o, n) {
+		return
+	}
+	var removed, added []string
+	if len(n) > 0 {
+		removed = missing(format(o), format(n))
+	}
+	if len(o) > 0 {
+		added = missing(format(n), format(o))
+	}
+	switch {
+	case len(o) == 0:
+		change("AllowedValues", o, n, true, "values restricted to "+strings.Join(format(n), ", "))
+	case len(removed) > 0:
+		change("AllowedValues", o, n, true, "values no longer allowed: "+strings.Join(removed, ", "))
+	case len(n) == 0:
+		change("AllowedValues", o, n, false, "values no longer restricted")
+	case len(added) > 0:
+		change("AllowedValues", o, n, false, "values now allowed: "+strings.Join(added, ", "))
+	}
+}
+
+func (d *differ) elem(scope, resource, path string, before, after *model.SchemaElement) {
+	var o, n model.SchemaElement
+	if before != nil {
+		o = *before
+	}
+	if after != nil {
+		n = *after
+	}
+	switch {
+	case o.Type == model.ElementTypeBackRef || n.Type == model.ElementTypeBackRef:
+		// Recursive schemas are compared where they are first exported
+		if o.Type != n.Type {
+			d.add(Change{Kind: Changed, Scope: scope, Resource: resource, Path: path, Field: "Elem", Before: o.Type, After: n.Type, Breaking: true,
+				Description: "element type changed"})
+		} else if o.Ref != n.Ref {
+			d.add(Change{Kind: Changed, Scope: scope, Resource: resource, Path: path, Field: "Elem", Before: o.Ref, After: n.Ref, Breaking: true,
+				Description: fmt.Sprintf("element now nests %s recursively instead of %s", n.Ref, o.Ref)})
+		}
+	case o.Info != nil || n.Info != nil:
+		if o.Info == nil || n.Info == nil {
+			d.add(Change{Kind: Changed, Scope: scope, Resource: resource, Path: path, Field: "Elem", Before: o.Type, After: n.Type, Breaking: true,
+				Description: "element type changed"})
+			return
+		}
+		d.info(scope, resource, path, o.Info, n.Info)
+	case o.Elements != nil && n.Elements != nil:
+		d.definition(scope, resource, path+".*", *o.Elements, *n.Elements)
+	default:
+		if elemType(o) != elemType(n) {
+			d.add(Change{Kind: Changed, Scope: scope, Resource: resource, Path: path + ".*", Field: "Type", Before: elemType(o), After: elemType(n), Breaking: true,
+				Description: fmt.Sprintf("element type changed from %s to %s", elemType(o), elemType(n))})
+		}
+	}
+}
+
+// elemType is the type of the elements of a collection, however the
+// element is described.
+func elemType(e model.SchemaElement) string {
+	switch {
+	case e.Elements != nil:
+		return e.Elements.Type
+	case e.ElementsType != "":
+		return e.ElementsType
+	}
+	return e.Value
+}
+
+// runtimeDefault stands in for the value of a DefaultFunc. That value
+// depends on the environment of the export, so only a change to or from
+// a DefaultFunc is reported, never a change of its value.
+const runtimeDefault = "(computed at runtime)"
+
+func defaultValue(d model.SchemaDefinition) interface{} {
+	if d.Default == nil {
+		return nil
+	}
+	if d.Default.Source == model.DefaultSourceFunc {
+		return runtimeDefault
+	}
+	return d.Default.Value
+}
+
+func defaultDescription(value interface{}) string {
+	if value == nil {
+		return "default removed"
+	}
+	if value == runtimeDefault {
+		return "default now computed at runtime"
+	}
+	return fmt.Sprintf("default changed to %v", value)
+}
+
+// limit renders an item limit, where 0 means unlimited.
+func limit(maxItems int) string {
+	if maxItems == 0 {
+		return "unlimited"
+	}
+	return fmt.Sprint(maxItems)
+}
+
+// tighter reports whether the limit after is lower than before, where
+// 0 means unlimited.
+func tighter(before, after int) bool {
+	return after > 0 && (before == 0 || after < before)
+}
+
+// missing returns the values of a that are not in b.
+func missing(a, b []string) []string {
+	in := make(map[string]bool, len(b))
+	for _, s := range b {
+		in[s] = true
+	}
+	var result []string
+	for _, s := range a {
+		if !in[s] {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func format(values []interface{}) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		result = append(result, fmt.Sprintf("%v", v))
+	}
+	return result
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func sortedKeys(names map[string]bool) []string {
+	result := make([]string, 0, len(names))
+	for name := range names {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,226 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package schemadiff
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/evan-cleary/tf-schema-extractor/model"
+	"github.com/evan-cleary/tf-schema-extractor/validators"
+)
+
+// compareResource compares the resource test_thing with the schemas
+// before and after.
+func compareResource(before, after model.SchemaInfoWithTimeouts) *Result {
+	o := model.NewResourceProviderSchema(&model.ProviderInfo{Name: "test"}, model.SDKTypeSDK2)
+	n := model.NewResourceProviderSchema(&model.ProviderInfo{Name: "test"}, model.SDKTypeSDK2)
+	o.Resources["test_thing"] = before
+	n.Resources["test_thing"] = after
+	return Compare(o, n)
+}
+
+// summary renders the changes of r compactly for comparison.
+func summary(r *Result) []string {
+	var result []string
+	for _, c := range r.Changes {
+		result = append(result, fmt.Sprintf("%s %s %s breaking=%t: %s", c.Kind, c.Path, c.Field, c.Breaking, c.Description))
+	}
+	return result
+}
+
+func TestCompareDefinition(t *testing.T) {
+	optional := model.SchemaDefinition{Type: "String", Optional: true}
+	with := func(f func(d *model.SchemaDefinition)) model.SchemaDefinition {
+		d := optional
+		f(&d)
+		return d
+	}
+	tests := []struct {
+		name   string
+		before model.SchemaDefinition
+		after  model.SchemaDefinition
+		want   string
+	}{
+		{"became required", optional, with(func(d *model.SchemaDefinition) { d.Optional, d.Required = false, true }),
+			"changed x Required breaking=true: became required"},
+		{"became optional", with(func(d *model.SchemaDefinition) { d.Optional, d.Required = false, true }), optional,
+			"changed x Required breaking=false: became optional"},
+		{"type changed", optional, with(func(d *model.SchemaDefinition) { d.Type = "Int" }),
+			"changed x Type breaking=true: type changed from String to Int"},
+		{"no longer configurable", optional, with(func(d *model.SchemaDefinition) { d.Optional, d.Computed = false, true }),
+			"changed x Computed breaking=true: is no longer configurable"},
+		{"now forces replacement", optional, with(func(d *model.SchemaDefinition) { d.ForceNew = true }),
+			"changed x ForceNew breaking=true: now forces replacement"},
+		{"no longer forces replacement", with(func(d *model.SchemaDefinition) { d.ForceNew = true }), optional,
+			"changed x ForceNew breaking=false: no longer forces replacement"},
+		{"new conflict", optional, with(func(d *model.SchemaDefinition) { d.ConflictsWith = []string{"y"} }),
+			"changed x ConflictsWith breaking=true: now conflicts with y"},
+		{"removed conflict", with(func(d *model.SchemaDefinition) { d.ConflictsWith = []string{"y"} }), optional,
+			"changed x ConflictsWith breaking=false: no longer conflicts with y"},
+		{"deprecated", optional, with(func(d *model.SchemaDefinition) { d.Deprecated = "Use y" }),
+			"changed x Deprecated breaking=false: deprecated: Use y"},
+		{"default changed", optional, with(func(d *model.SchemaDefinition) {
+			d.Default = &model.SchemaDefault{Source: model.DefaultSourceStatic, Value: "a"}
+		}), "changed x Default breaking=true: default changed to a"},
+		{"default now computed", with(func(d *model.SchemaDefinition) {
+			d.Default = &model.SchemaDefault{Source: model.DefaultSourceStatic, Value: "a"}
+		}), with(func(d *model.SchemaDefinition) {
+			d.Default = &model.SchemaDefault{Source: model.DefaultSourceFunc}
+		}), "changed x Default breaking=true: default now computed at runtime"},
+		{"DefaultFunc value ignored", with(func(d *model.SchemaDefinition) {
+			d.Default = &model.SchemaDefault{Source: model.DefaultSourceFunc, Value: "a"}
+		}), with(func(d *model.SchemaDefinition) {
+			d.Default = &model.SchemaDefault{Source: model.DefaultSourceFunc, Value: "b"}
+		}), ""},
+		{"values restricted", optional, with(func(d *model.SchemaDefinition) {
+			d.Validation = &validators.Constraints{AllowedValues: []interface{}{"a", "b"}}
+		}), "changed x AllowedValues breaking=true: values restricted to a, b"},
+		{"value removed", with(func(d *model.SchemaDefinition) {
+			d.Validation = &validators.Constraints{AllowedValues: []interface{}{"a", "b"}}
+		}), with(func(d *model.SchemaDefinition) {
+			d.Validation = &validators.Constraints{AllowedValues: []interface{}{"a"}}
+		}), "changed x AllowedValues breaking=true: values no longer allowed: b"},
+		{"value added", with(func(d *model.SchemaDefinition) {
+			d.Validation = &validators.Constraints{AllowedValues: []interface{}{"a"}}
+		}), with(func(d *model.SchemaDefinition) {
+			d.Validation = &validators.Constraints{AllowedValues: []interface{}{"a", "b"}}
+		}), "changed x AllowedValues breaking=false: values now allowed: b"},
+		{"max items tightened", with(func(d *model.SchemaDefinition) { d.Type, d.MaxItems = "List", 5 }),
+			with(func(d *model.SchemaDefinition) { d.Type, d.MaxItems = "List", 2 }),
+			"changed x MaxItems breaking=true: max items changed from 5 to 2"},
+		{"max items lifted", with(func(d *model.SchemaDefinition) { d.Type, d.MaxItems = "List", 5 }),
+			with(func(d *model.SchemaDefinition) { d.Type = "List" }),
+			"changed x MaxItems breaking=false: max items changed from 5 to unlimited"},
+		{"sensitive", optional, with(func(d *model.SchemaDefinition) { d.Sensitive = true }),
+			"changed x Sensitive breaking=false: sensitive changed to true"},
+		{"description", optional, with(func(d *model.SchemaDefinition) { d.Description = "The x" }),
+			"changed x Description breaking=false: description changed"},
+		{"single nesting", with(func(d *model.SchemaDefinition) { d.Type, d.MaxItems = "List", 1 }),
+			with(func(d *model.SchemaDefinition) { d.Type, d.MaxItems, d.Nesting = "List", 1, model.NestingSingle }),
+			"changed x Nesting breaking=true: nesting changed"},
+		{"element type", with(func(d *model.SchemaDefinition) {
+			d.Type, d.Elem = "List", &model.SchemaElement{Type: "SchemaElements", ElementsType: "String", Elements: &model.SchemaDefinition{Type: "String"}}
+		}), with(func(d *model.SchemaDefinition) {
+			d.Type, d.Elem = "List", &model.SchemaElement{Type: "SchemaElements", ElementsType: "Int", Elements: &model.SchemaDefinition{Type: "Int"}}
+		}), "changed x.* Type breaking=true: type changed from String to Int"},
+		{"recursion changed", with(func(d *model.SchemaDefinition) {
+			d.Type, d.Elem = "List", &model.SchemaElement{Type: model.ElementTypeBackRef, Ref: "test_thing"}
+		}), with(func(d *model.SchemaDefinition) {
+			d.Type, d.Elem = "List", &model.SchemaElement{Type: model.ElementTypeBackRef, Ref: "test_thing.y"}
+		}), "changed x Elem breaking=true: element now nests test_thing.y recursively instead of test_thing"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := compareResource(model.SchemaInfoWithTimeouts{"x": test.before}, model.SchemaInfoWithTimeouts{"x": test.after})
+			if test.want == "" {
+				if got := summary(r); len(got) != 0 {
+					t.Errorf("Compare() = %q, want no changes", got)
+				}
+				return
+			}
+			if got := summary(r); len(got) != 1 || got[0] != test.want {
+				t.Errorf("Compare() = %q, want [%q]", got, test.want)
+			}
+			if r.Breaking() != r.Changes[0].Breaking {
+				t.Errorf("Breaking() = %t", r.Breaking())
+			}
+		})
+	}
+}
+
+func TestCompareAttributes(t *testing.T) {
+	before := model.SchemaInfoWithTimeouts{
+		"kept":    {Type: "String", Optional: true},
+		"removed": {Type: "String", Optional: true},
+		"rule": {Type: "List", Optional: true, IsBlock: true, Elem: &model.SchemaElement{Type: "SchemaInfo", Info: model.SchemaInfo{
+			"cidr": {Type: "String", Optional: true},
+		}}},
+		model.TimeoutsConfigKey: model.SingleBlock(model.SchemaInfo{model.TimeoutCreate: {Type: "String", Optional: true,
+			Default: &model.SchemaDefault{Source: model.DefaultSourceStatic, Value: "10m0s"}}}),
+	}
+	after := model.SchemaInfoWithTimeouts{
+		"kept":     {Type: "String", Optional: true},
+		"optional": {Type: "String", Optional: true},
+		"required": {Type: "String", Required: true},
+		"read":     {Type: "String", Computed: true},
+		"block":    {Type: "List", Optional: true, IsBlock: true, MinItems: 1},
+		"rule": {Type: "List", Optional: true, IsBlock: true, Elem: &model.SchemaElement{Type: "SchemaInfo", Info: model.SchemaInfo{
+			"cidr": {Type: "String", Required: true},
+		}}},
+		model.TimeoutsConfigKey: model.SingleBlock(model.SchemaInfo{model.TimeoutCreate: {Type: "String", Optional: true,
+			Default: &model.SchemaDefault{Source: model.DefaultSourceStatic, Value: "20m0s"}}}),
+	}
+	want := []string{
+		"added block  breaking=false: added",
+		"added optional  breaking=false: added",
+		"added read  breaking=false: added",
+		"removed removed  breaking=true: removed",
+		"added required  breaking=true: added as required",
+		"changed rule.cidr Required breaking=true: became required",
+		// Timeouts only bound how long Terraform waits
+		"changed timeouts.create Default breaking=false: default changed to 20m0s",
+	}
+	r := compareResource(before, after)
+	got := summary(r)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Compare() =\n%q\nwant\n%q", got, want)
+	}
+	for _, c := range r.Changes {
+		if c.Path == "read" && !c.ReadOnly || c.Path != "read" && c.ReadOnly {
+			t.Errorf("%s: read only = %t", c.Path, c.ReadOnly)
+		}
+	}
+}
+
+func TestCompareResources(t *testing.T) {
+	before := model.NewResourceProviderSchema(&model.ProviderInfo{Name: "test"}, model.SDKTypeSDK2)
+	after := model.NewResourceProviderSchema(&model.ProviderInfo{Name: "test"}, model.SDKTypeSDK2)
+	before.Resources["test_old"] = model.SchemaInfoWithTimeouts{}
+	before.Resources["test_kept"] = model.SchemaInfoWithTimeouts{}
+	before.ResourceHeaders["test_kept"] = model.ResourceHeader{Importable: true}
+	after.Resources["test_kept"] = model.SchemaInfoWithTimeouts{}
+	after.ResourceHeaders["test_kept"] = model.ResourceHeader{SchemaVersion: 1, DeprecationMessage: "Use test_new"}
+	after.Resources["test_new"] = model.SchemaInfoWithTimeouts{}
+	before.DataSources["test_info"] = model.SchemaInfoWithTimeouts{}
+	after.Provider["region"] = model.SchemaDefinition{Type: "String", Required: true}
+
+	var got []string
+	r := Compare(before, after)
+	for _, c := range r.Changes {
+		got = append(got, fmt.Sprintf("%s: %s breaking=%t", c.Subject(), c.Description, c.Breaking))
+	}
+	want := []string{
+		"provider attribute region: added as required breaking=true",
+		"resource test_kept: deprecated: Use test_new breaking=false",
+		"resource test_kept: state schema version changed from 0 to 1 breaking=false",
+		"resource test_kept: can no longer be imported breaking=true",
+		"resource test_new: added breaking=false",
+		"resource test_old: removed breaking=true",
+		"data source test_info: removed breaking=true",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Compare() =\n%q\nwant\n%q", got, want)
+	}
+
+	breaking, other := r.Split()
+	if len(breaking) != 4 || len(other) != 3 || !r.Breaking() {
+		t.Errorf("Split() = %d breaking and %d other changes, want 4 and 3", len(breaking), len(other))
+	}
+	if r := Compare(before, before); len(r.Changes) != 0 || r.Breaking() {
+		t.Errorf("Compare() of equal schemas = %q", summary(r))
+	}
+}
@@ -0,0 +1,126 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package extractor
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/evan-cleary/tf-schema-extractor/model"
+	"github.com/evan-cleary/tf-schema-extractor/validators"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+func TestInspectValidateFunc(t *testing.T) {
+	one, ten, half := 1.0, 10.0, 0.5
+	minLength, maxLength := 1, 64
+	tests := []struct {
+		name      string
+		fn        schema.SchemaValidateFunc
+		valueType string
+		want      *validators.Constraints
+	}{
+		{
+			name:      "StringInSlice",
+			fn:        validation.StringInSlice([]string{"a", "b c"}, false),
+			valueType: "String",
+			want:      &validators.Constraints{AllowedValues: []interface{}{"a", "b c"}},
+		},
+		{
+			name:      "IntBetween",
+			fn:        validation.IntBetween(1, 10),
+			valueType: "Int",
+			want:      &validators.Constraints{Minimum: &one, Maximum: &ten},
+		},
+		{
+			name:      "FloatAtLeast",
+			fn:        validation.FloatAtLeast(0.5),
+			valueType: "Float",
+			want:      &validators.Constraints{Minimum: &half},
+		},
+		{
+			name:      "StringLenBetween",
+			fn:        validation.StringLenBetween(1, 64),
+			valueType: "String",
+			want:      &validators.Constraints{MinLength: &minLength, MaxLength: &maxLength},
+		},
+		{
+			name: "All",
+			fn: validation.All(
+				validation.StringLenBetween(1, 64),
+				validation.StringMatch(regexp.MustCompile(`^[a-z]+$`), ""),
+			),
+			valueType: "String",
+			want:      &validators.Constraints{MinLength: &minLength, MaxLength: &maxLength, Pattern: `^[a-z]+$`},
+		},
+		{
+			name:      "Any",
+			fn:        validation.Any(validation.IntAtLeast(10), validation.IntAtMost(0)),
+			valueType: "Int",
+		},
+		{
+			name:      "unsupported type",
+			fn:        validation.StringInSlice([]string{"a"}, false),
+			valueType: "Bool",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := inspectValidateFunc(test.valueType, test.fn); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("inspectValidateFunc() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestExportDefault(t *testing.T) {
+	called := false
+	tests := []struct {
+		name   string
+		schema *schema.Schema
+		want   *SchemaDefault
+	}{
+		{
+			name:   "none",
+			schema: &schema.Schema{Type: schema.TypeString, Optional: true},
+		},
+		{
+			name:   "static",
+			schema: &schema.Schema{Type: schema.TypeBool, Optional: true, Default: false},
+			want:   &SchemaDefault{Source: model.DefaultSourceStatic, Value: false},
+		},
+		{
+			name: "sensitive DefaultFunc",
+			schema: &schema.Schema{Type: schema.TypeString, Optional: true, Sensitive: true, DefaultFunc: func() (interface{}, error) {
+				called = true
+				return "s3cr3t", nil
+			}},
+			want: &SchemaDefault{Source: model.DefaultSourceFunc},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := exportDefault(test.schema); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("exportDefault() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+	if called {
+		t.Error("exportDefault called DefaultFunc")
+	}
+}
@@ -0,0 +1,75 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package extractor
+
+import (
+	"bytes"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func TestEncodeWorkers(t *testing.T) {
+	// Validators need not be thread-safe, so probing them must never run
+	// concurrently
+	var running, overlaps, calls int32
+	unsafe := func(interface{}, string) ([]string, []error) {
+		if atomic.AddInt32(&running, 1) > 1 {
+			atomic.AddInt32(&overlaps, 1)
+		}
+		time.Sleep(50 * time.Microsecond)
+		atomic.AddInt32(&running, -1)
+		atomic.AddInt32(&calls, 1)
+		return nil, nil
+	}
+	p := &schema.Provider{ResourcesMap: make(map[string]*schema.Resource)}
+	for i := 0; i < 50; i++ {
+		p.ResourcesMap[fmt.Sprintf("test_r%02d", i)] = &schema.Resource{Schema: map[string]*schema.Schema{
+			"name": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.All(validation.StringLenBetween(1, 64), unsafe),
+			},
+		}}
+	}
+	pi := &ProviderInfo{Name: "test", Revision: "1.0.0"}
+
+	var serial bytes.Buffer
+	if err := new(Sdk2Extractor).Encode(p, pi, &serial); err != nil {
+		t.Fatal(err)
+	}
+	serialCalls := atomic.LoadInt32(&calls)
+	if serialCalls == 0 {
+		t.Fatal("validators were not probed")
+	}
+	var concurrent bytes.Buffer
+	if err := (&Sdk2Extractor{Workers: 8}).Encode(p, pi, &concurrent); err != nil {
+		t.Fatal(err)
+	}
+	if concurrent.String() != serial.String() {
+		t.Errorf("output with 8 workers differs from the serial output")
+	}
+	if overlaps != 0 {
+		t.Errorf("validators ran concurrently %d times", overlaps)
+	}
+	if calls != 2*serialCalls {
+		t.Errorf("validators called %d times, want %d", calls, 2*serialCalls)
+	}
+}
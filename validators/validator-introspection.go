@@ -0,0 +1,257 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package validators
+
+import (
+	"math"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Constraints are the restrictions a validator places on a value.
+type Constraints struct {
+	// Set for StringInSlice and IntInSlice
+	AllowedValues []interface{} `json:",omitempty"`
+	// Set for IntBetween, IntAtLeast, FloatBetween and FloatAtLeast
+	Minimum *float64 `json:",omitempty"`
+	// Set for IntBetween, IntAtMost, FloatBetween and FloatAtMost
+	Maximum *float64 `json:",omitempty"`
+	// Set for StringLenBetween
+	MinLength *int `json:",omitempty"`
+	MaxLength *int `json:",omitempty"`
+	// Set for StringMatch without a custom error message
+	Pattern string `json:",omitempty"`
+	// Self-descriptions of validators whose constraints cannot be
+	// inferred, such as terraform-plugin-framework validators
+	Descriptions []string `json:",omitempty"`
+}
+
+// Probe runs the validator under inspection against value and returns
+// the messages of the errors it reports.
+type Probe func(value interface{}) []string
+
+// helpers are the SDK helper/validation functions whose closures are
+// probed. All is included because it reports the errors of every
+// wrapped validator; Any is not, since its constraints are alternatives.
+// ToDiagFunc adapts a SchemaValidateFunc for ValidateDiagFunc.
+var helpers = map[string]bool{
+	"StringInSlice":    true,
+	"StringLenBetween": true,
+	"StringMatch":      true,
+	"IntInSlice":       true,
+	"IntBetween":       true,
+	"IntAtLeast":       true,
+	"IntAtMost":        true,
+	"FloatBetween":     true,
+	"FloatAtLeast":     true,
+	"FloatAtMost":      true,
+	"All":              true,
+	"ToDiagFunc":       true,
+}
+
+const (
+	maxInt = int(^uint(0) >> 1)
+	minInt = -maxInt - 1
+)
+
+var (
+	oneOfPattern   = regexp.MustCompile(`to be one of \[(.*)\], got `)
+	rangePattern   = regexp.MustCompile(`^expected (length of )?.* to be in the range \((\S+) - (\S+)\), got `)
+	atLeastPattern = regexp.MustCompile(`to be at least \((\S+)\), got `)
+	atMostPattern  = regexp.MustCompile(`to be at most \((\S+)\), got `)
+	matchPattern   = regexp.MustCompile(`to match regular expression (".*"), got `)
+)
+
+// FuncName returns the fully qualified name of the function fn, such as
+// "github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation.StringInSlice.func1".
+func FuncName(fn interface{}) string {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return ""
+	}
+	if f := runtime.FuncForPC(v.Pointer()); f != nil {
+		return f.Name()
+	}
+	return ""
+}
+
+// helperName returns the name of the helper/validation function that
+// created the closure called name, or "" for any other function.
+func helperName(name string) string {
+	const pkg = "/helper/validation."
+	i := strings.LastIndex(name, pkg)
+	if i < 0 {
+		return ""
+	}
+	name = name[i+len(pkg):]
+	if i := strings.Index(name, "."); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// Inspect infers the constraints of a validator from the errors it
+// reports for out-of-range probe values. name is the validator's
+// function name as returned by FuncName and valueType the shortened
+// schema type of the validated value. Only closures created by the
+// SDK's helper/validation package are probed; nil is returned for
+// other validators and when nothing could be inferred.
+func Inspect(name string, valueType string, probe Probe) *Constraints {
+	if !helpers[helperName(name)] {
+		return nil
+	}
+	var probes []interface{}
+	switch valueType {
+	case "String":
+		probes = []interface{}{"\x00", "", strings.Repeat("x", 1<<16)}
+	case "Int":
+		probes = []interface{}{minInt, maxInt}
+	case "Float":
+		probes = []interface{}{-math.MaxFloat64, math.MaxFloat64}
+	default:
+		return nil
+	}
+
+	c := &Constraints{}
+	found := false
+	for _, value := range probes {
+		for _, message := range safeProbe(probe, value) {
+			if c.parse(message, valueType, probe) {
+				found = true
+			}
+		}
+	}
+	if !found {
+		return nil
+	}
+	return c
+}
+
+// safeProbe calls probe, treating a panicking validator as one that
+// reports nothing.
+func safeProbe(probe Probe, value interface{}) (messages []string) {
+	defer func() {
+		if recover() != nil {
+			messages = nil
+		}
+	}()
+	return probe(value)
+}
+
+// parse records the constraint described by message, reporting whether
+// it was one of the helper/validation messages.
+func (c *Constraints) parse(message string, valueType string, probe Probe) bool {
+	if m := oneOfPattern.FindStringSubmatch(message); m != nil {
+		if c.AllowedValues == nil {
+			c.AllowedValues = allowedValues(m[1], valueType, probe)
+		}
+		return c.AllowedValues != nil
+	}
+	if m := rangePattern.FindStringSubmatch(message); m != nil {
+		if m[1] != "" {
+			min, minErr := strconv.Atoi(m[2])
+			max, maxErr := strconv.Atoi(m[3])
+			if minErr != nil || maxErr != nil {
+				return false
+			}
+			c.MinLength, c.MaxLength = &min, &max
+			return true
+		}
+		min, minOk := parseNumber(m[2])
+		max, maxOk := parseNumber(m[3])
+		if !minOk || !maxOk {
+			return false
+		}
+		c.Minimum, c.Maximum = &min, &max
+		return true
+	}
+	if m := atLeastPattern.FindStringSubmatch(message); m != nil {
+		if min, ok := parseNumber(m[1]); ok {
+			c.Minimum = &min
+			return true
+		}
+		return false
+	}
+	if m := atMostPattern.FindStringSubmatch(message); m != nil {
+		if max, ok := parseNumber(m[1]); ok {
+			c.Maximum = &max
+			return true
+		}
+		return false
+	}
+	if m := matchPattern.FindStringSubmatch(message); m != nil {
+		pattern, err := strconv.Unquote(m[1])
+		if err != nil {
+			return false
+		}
+		c.Pattern = pattern
+		return true
+	}
+	return false
+}
+
+// allowedValues splits the %v rendering of a slice of valid values.
+// String values may themselves contain spaces, so adjacent fields are
+// joined until the validator accepts the candidate.
+func allowedValues(list string, valueType string, probe Probe) []interface{} {
+	fields := strings.Split(list, " ")
+	var values []interface{}
+	if valueType == "Int" {
+		for _, field := range fields {
+			n, err := strconv.ParseInt(field, 10, 64)
+			if err != nil {
+				return nil
+			}
+			values = append(values, n)
+		}
+		return values
+	}
+
+	candidate := ""
+	for i, field := range fields {
+		if i > 0 && candidate != "" {
+			candidate += " "
+		}
+		candidate += field
+		if isAllowed(probe, candidate) {
+			values = append(values, candidate)
+			candidate = ""
+		}
+	}
+	if candidate != "" {
+		return nil
+	}
+	return values
+}
+
+// isAllowed reports whether value passes the slice check of the
+// validator. Other checks combined through All are ignored.
+func isAllowed(probe Probe, value string) bool {
+	for _, message := range safeProbe(probe, value) {
+		if oneOfPattern.MatchString(message) {
+			return false
+		}
+	}
+	return true
+}
+
+func parseNumber(value string) (float64, bool) {
+	f, err := strconv.ParseFloat(value, 64)
+	return f, err == nil
+}
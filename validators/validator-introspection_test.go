@@ -0,0 +1,189 @@
+/*
+   Copyright 2021 Evan Cleary
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+package validators_test
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/evan-cleary/tf-schema-extractor/validators"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// probeFunc probes a SchemaValidateFunc like the extractors do. The
+// helpers of SDK v1 cannot be linked into the same binary as those of
+// v2; they are tested with the v1 extractor.
+func probeFunc(f schema.SchemaValidateFunc) validators.Probe {
+	return func(value interface{}) []string {
+		var messages []string
+		_, errs := f(value, "probe")
+		for _, err := range errs {
+			messages = append(messages, err.Error())
+		}
+		return messages
+	}
+}
+
+// probeDiagFunc probes a SchemaValidateDiagFunc.
+func probeDiagFunc(f schema.SchemaValidateDiagFunc) validators.Probe {
+	return func(value interface{}) []string {
+		var messages []string
+		for _, d := range f(value, cty.GetAttrPath("probe")) {
+			if d.Severity == diag.Error {
+				messages = append(messages, d.Summary)
+			}
+		}
+		return messages
+	}
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func TestInspect(t *testing.T) {
+	var noop schema.SchemaValidateFunc = func(interface{}, string) ([]string, []error) { return nil, nil }
+	tests := []struct {
+		name      string
+		fn        interface{}
+		probe     validators.Probe
+		valueType string
+		want      *validators.Constraints
+	}{
+		{
+			name:      "StringInSlice",
+			fn:        validation.StringInSlice([]string{"a", "b c"}, false),
+			valueType: "String",
+			want:      &validators.Constraints{AllowedValues: []interface{}{"a", "b c"}},
+		},
+		{
+			name:      "IntBetween",
+			fn:        validation.IntBetween(-5, 5),
+			valueType: "Int",
+			want:      &validators.Constraints{Minimum: floatPtr(-5), Maximum: floatPtr(5)},
+		},
+		{
+			name:      "FloatAtLeast",
+			fn:        validation.FloatAtLeast(-1.25),
+			valueType: "Float",
+			want:      &validators.Constraints{Minimum: floatPtr(-1.25)},
+		},
+		{
+			name:      "StringLenBetween",
+			fn:        validation.StringLenBetween(0, 255),
+			valueType: "String",
+			want:      &validators.Constraints{MinLength: intPtr(0), MaxLength: intPtr(255)},
+		},
+		{
+			name: "All",
+			fn: validation.All(
+				validation.StringInSlice([]string{"x", "y"}, false),
+				validation.StringLenBetween(1, 1),
+			),
+			valueType: "String",
+			want:      &validators.Constraints{AllowedValues: []interface{}{"x", "y"}, MinLength: intPtr(1), MaxLength: intPtr(1)},
+		},
+		{
+			name:      "ToDiagFunc",
+			fn:        validation.ToDiagFunc(validation.IntBetween(1, 10)),
+			probe:     probeDiagFunc(validation.ToDiagFunc(validation.IntBetween(1, 10))),
+			valueType: "Int",
+			want:      &validators.Constraints{Minimum: floatPtr(1), Maximum: floatPtr(10)},
+		},
+		{
+			name:      "custom StringMatch message",
+			fn:        validation.StringMatch(regexp.MustCompile(`^x$`), "must be x"),
+			valueType: "String",
+		},
+		{
+			name:      "alternatives",
+			fn:        validation.Any(validation.IntAtLeast(10), validation.IntAtMost(0)),
+			valueType: "Int",
+		},
+		{
+			name:      "unsupported type",
+			fn:        validation.StringInSlice([]string{"a"}, false),
+			valueType: "Bool",
+		},
+		{
+			name:      "not a helper",
+			fn:        noop,
+			valueType: "String",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			probe := test.probe
+			if probe == nil {
+				probe = probeFunc(test.fn.(schema.SchemaValidateFunc))
+			}
+			got := validators.Inspect(validators.FuncName(test.fn), test.valueType, probe)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Inspect() = %s, want %s", describe(got), describe(test.want))
+			}
+		})
+	}
+}
+
+func TestInspectPanickingValidator(t *testing.T) {
+	// A helper called with a value of the wrong type panics
+	fn := validation.IntBetween(1, 10)
+	probe := func(value interface{}) []string {
+		_, errs := fn(value.(bool), "probe")
+		return []string{errs[0].Error()}
+	}
+	if got := validators.Inspect(validators.FuncName(fn), "Int", probe); got != nil {
+		t.Errorf("Inspect() = %s, want nil", describe(got))
+	}
+}
+
+func TestFuncName(t *testing.T) {
+	const pkg = "github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation."
+	if got, want := validators.FuncName(validation.IntAtLeast(1)), pkg+"IntAtLeast.func1"; got != want {
+		t.Errorf("FuncName() = %q, want %q", got, want)
+	}
+	var nilFunc func()
+	for _, fn := range []interface{}{nil, nilFunc, "IntAtLeast"} {
+		if got := validators.FuncName(fn); got != "" {
+			t.Errorf("FuncName(%#v) = %q, want \"\"", fn, got)
+		}
+	}
+}
+
+// describe renders c with the values behind its pointers.
+func describe(c *validators.Constraints) string {
+	if c == nil {
+		return "nil"
+	}
+	deref := func(p interface{}) interface{} {
+		v := reflect.ValueOf(p)
+		if v.IsNil() {
+			return nil
+		}
+		return v.Elem().Interface()
+	}
+	return fmt.Sprintf("{AllowedValues:%v Minimum:%v Maximum:%v MinLength:%v MaxLength:%v Pattern:%q}",
+		c.AllowedValues, deref(c.Minimum), deref(c.Maximum), deref(c.MinLength), deref(c.MaxLength), c.Pattern)
+}